@@ -7,53 +7,89 @@ import (
 	"net/http"
 	clients "toy/internal"
 	"toy/internal/api"
+	"toy/internal/logging"
+	"toy/internal/metrics"
+	"toy/internal/telemetry"
 
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
-	"go.opentelemetry.io/otel"
-	"go.opentelemetry.io/otel/exporters/jaeger"
-	"go.opentelemetry.io/otel/propagation"
-	"go.opentelemetry.io/otel/sdk/resource"
-	sdktrace "go.opentelemetry.io/otel/sdk/trace"
-	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
 )
 
 var (
 	addr              = flag.String("addr", ":8080", "")
-	authenticatorAddr = flag.String("authenticatorAddr", ":8082", "")
-	userAddr          = flag.String("userAddr", ":8081", "")
+	authenticatorAddr = flag.String("authenticatorAddr", ":8082", "authenticator address, a comma separated list of addresses or a dns:///, file:/// target")
+	userAddr          = flag.String("userAddr", ":8081", "user service address, a comma separated list of addresses or a dns:///, file:/// target")
+	calculatorAddr    = flag.String("calculatorAddr", ":8083", "calculator address, a comma separated list of addresses or a dns:///, file:/// target")
+	metricsAddr       = flag.String("metricsAddr", ":9080", "")
+	authSampleRatio   = flag.Float64("authSampleRatio", 1, "ratio of /auth requests to sample, of the failed requests left out only the gateway span is exported, not the downstream spans")
+
+	traceCfg  = telemetry.RegisterFlags(flag.CommandLine)
+	clientCfg = clients.RegisterFlags(flag.CommandLine)
+	logLevel  = logging.RegisterFlags(flag.CommandLine)
 )
 
 func main() {
 	flag.Parse()
 
-	exporter, err := jaeger.New(jaeger.WithCollectorEndpoint(jaeger.WithEndpoint("http://localhost:14260/api/traces")))
+	logger, err := logging.New("api-gateway", *logLevel)
 	if err != nil {
 		log.Fatal(err)
 	}
+	defer logger.Sync()
 
-	// exporter, err := zipkin.New("http://localhost:9411/api/v2/spans")
-	// if err != nil {
-	// 	log.Fatal(err)
-	// }
+	traceCfg.ServiceName = "api-gateway"
+	sampler, err := traceCfg.BaseSampler()
+	if err != nil {
+		log.Fatal(err)
+	}
+	traceCfg.Sampler = telemetry.RuleBased(sampler, telemetry.SamplingRule{
+		Route:        "/auth",
+		Ratio:        *authSampleRatio,
+		SampleErrors: true,
+	})
 
-	tp := sdktrace.NewTracerProvider(
-		sdktrace.WithSampler(sdktrace.AlwaysSample()),
-		sdktrace.WithBatcher(exporter),
-		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceNameKey.String("api-gateway"))),
-	)
-	otel.SetTracerProvider(tp)
-	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	shutdown, err := telemetry.Setup(context.Background(), *traceCfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer shutdown(context.Background())
 
-	defer tp.Shutdown(context.Background())
+	userClient, err := clients.NewUserClient(*userAddr, *clientCfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer userClient.Close()
 
-	userClient := clients.NewUserClient(*userAddr)
-	authClient := clients.NewAuthenticatorClient(*authenticatorAddr)
+	authClient, err := clients.NewAuthenticatorClient(*authenticatorAddr, *clientCfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer authClient.Close()
 
-	svc := api.NewService(userClient, authClient)
-	srv := api.NewServer(svc)
+	calcClient, err := clients.NewCalculatorClient(*calculatorAddr, *clientCfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer calcClient.Close()
+
+	svc := api.NewService(userClient, authClient, calcClient)
+	srv := api.NewServer(svc, logger)
 
-	handler := otelhttp.NewHandler(http.HandlerFunc(srv.AuthenticatePassword), "/auth")
-	http.Handle("/auth", handler)
+	m := metrics.New()
+	go func() {
+		log.Fatal(m.ListenAndServe(*metricsAddr))
+	}()
+
+	handle := func(route string, h http.Handler) {
+		http.Handle(route, otelhttp.NewHandler(m.HTTPHandler(route, h), route))
+	}
+	handle("/auth", http.HandlerFunc(srv.AuthenticatePassword))
+	handle("/me", srv.Authenticate(http.HandlerFunc(srv.Me)))
+	handle("/calculator/add", http.HandlerFunc(srv.Add))
+	handle("/calculator/substract", http.HandlerFunc(srv.Substract))
+	handle("/calculator/multiply", http.HandlerFunc(srv.Multiply))
+	handle("/calculator/divide", http.HandlerFunc(srv.Divide))
+	handle("/calculator/evaluate", http.HandlerFunc(srv.Evaluate))
+	handle("/calculator/expression", http.HandlerFunc(srv.EvaluateExpression))
 
 	if err := http.ListenAndServe(*addr, nil); err != nil {
 		log.Fatal(err)
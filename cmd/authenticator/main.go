@@ -8,53 +8,71 @@ import (
 	clients "toy/internal"
 	"toy/internal/authenticator"
 	"toy/internal/jwt"
+	"toy/internal/logging"
+	"toy/internal/metrics"
+	"toy/internal/telemetry"
 	"toy/schema/authenticatorgrpc"
 
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
-	"go.opentelemetry.io/otel"
-	"go.opentelemetry.io/otel/exporters/jaeger"
-	"go.opentelemetry.io/otel/propagation"
-	"go.opentelemetry.io/otel/sdk/resource"
-	sdktrace "go.opentelemetry.io/otel/sdk/trace"
-	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
 	"google.golang.org/grpc"
 )
 
 var addr = flag.String("addr", ":8082", "")
-var userAddr = flag.String("userAddr", ":8081", "")
+var userAddr = flag.String("userAddr", ":8081", "user service address, a comma separated list of addresses or a dns:///, file:/// target")
+var metricsAddr = flag.String("metricsAddr", ":9082", "")
+var jwtAlgorithm = flag.String("jwtAlgorithm", "HS256", "token signing algorithm, HS256, RS256, ES256 or EdDSA")
+var jwtKey = flag.String("jwtKey", "", "file holding the HS256 secret or the PEM encoded private key, shared by all instances")
+var jwtPublicKey = flag.String("jwtPublicKey", "", "file holding the PEM encoded public key, derived from the private key when empty")
+var traceCfg = telemetry.RegisterFlags(flag.CommandLine)
+var clientCfg = clients.RegisterFlags(flag.CommandLine)
+var logLevel = logging.RegisterFlags(flag.CommandLine)
 
 func main() {
 	flag.Parse()
 
-	exporter, err := jaeger.New(jaeger.WithCollectorEndpoint(jaeger.WithEndpoint("http://localhost:14260/api/traces")))
+	logger, err := logging.New("authenticator", *logLevel)
 	if err != nil {
 		log.Fatal(err)
 	}
+	defer logger.Sync()
 
-	// exporter, err := zipkin.New("http://localhost:9411/api/v2/spans")
-	// if err != nil {
-	// 	log.Fatal(err)
-	// }
-	tp := sdktrace.NewTracerProvider(
-		sdktrace.WithSampler(sdktrace.AlwaysSample()),
-		sdktrace.WithBatcher(exporter),
-		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceNameKey.String("authenticator"))),
-	)
-	otel.SetTracerProvider(tp)
-	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	traceCfg.ServiceName = "authenticator"
+	shutdown, err := telemetry.Setup(context.Background(), *traceCfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer shutdown(context.Background())
 
-	defer tp.Shutdown(context.Background())
+	m := metrics.New()
+	go func() {
+		log.Fatal(m.ListenAndServe(*metricsAddr))
+	}()
 
 	srv := grpc.NewServer(
-		grpc.UnaryInterceptor(otelgrpc.UnaryServerInterceptor()),
-		grpc.StreamInterceptor(otelgrpc.StreamServerInterceptor()),
+		grpc.ChainUnaryInterceptor(otelgrpc.UnaryServerInterceptor(), m.UnaryServerInterceptor()),
+		grpc.ChainStreamInterceptor(otelgrpc.StreamServerInterceptor(), m.StreamServerInterceptor()),
+		clients.ServerKeepalive(),
 	)
 	lis, err := net.Listen("tcp", *addr)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	svc := authenticator.NewAuthenticator(clients.NewUserClient(*userAddr), jwt.NewHS256Wrapper("secret"))
+	userClient, err := clients.NewUserClient(*userAddr, *clientCfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer userClient.Close()
+	if b := userClient.Breaker(); b != nil {
+		m.Register(b.Collector())
+	}
+
+	jwtWrapper, err := jwt.LoadWrapper(*jwtAlgorithm, *jwtKey, *jwtPublicKey)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	svc := authenticator.NewAuthenticator(userClient, jwtWrapper, logger)
 	authServer := &authenticator.Server{A: svc}
 	authenticatorgrpc.RegisterAuthenticatorServer(srv, authServer)
 
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net"
+	clients "toy/internal"
+	"toy/internal/calculator"
+	"toy/internal/logging"
+	"toy/internal/metrics"
+	"toy/internal/telemetry"
+	"toy/schema/calculatorgrpc"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+)
+
+var addr = flag.String("addr", ":8083", "")
+var metricsAddr = flag.String("metricsAddr", ":9083", "")
+var traceCfg = telemetry.RegisterFlags(flag.CommandLine)
+var logLevel = logging.RegisterFlags(flag.CommandLine)
+
+func main() {
+	flag.Parse()
+
+	logger, err := logging.New("calculator", *logLevel)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer logger.Sync()
+
+	traceCfg.ServiceName = "calculator"
+	shutdown, err := telemetry.Setup(context.Background(), *traceCfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer shutdown(context.Background())
+
+	calculatorServer := &calculator.Server{
+		Svc: calculator.NewService(logger),
+	}
+
+	m := metrics.New()
+	go func() {
+		log.Fatal(m.ListenAndServe(*metricsAddr))
+	}()
+
+	srv := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(otelgrpc.UnaryServerInterceptor(), m.UnaryServerInterceptor()),
+		grpc.ChainStreamInterceptor(otelgrpc.StreamServerInterceptor(), m.StreamServerInterceptor()),
+		clients.ServerKeepalive(),
+	)
+	lis, err := net.Listen("tcp", *addr)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	calculatorgrpc.RegisterCalculatorServer(srv, calculatorServer)
+
+	if err := srv.Serve(lis); err != nil {
+		log.Fatal(err)
+	}
+}
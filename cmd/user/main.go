@@ -2,60 +2,67 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
+	"fmt"
 	"log"
 	"net"
+	clients "toy/internal"
+	"toy/internal/logging"
+	"toy/internal/metrics"
+	"toy/internal/telemetry"
 	user "toy/internal/user"
 	"toy/schema/usergrpc"
 
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
-	"go.opentelemetry.io/otel"
-	"go.opentelemetry.io/otel/exporters/jaeger"
-	"go.opentelemetry.io/otel/propagation"
-	"go.opentelemetry.io/otel/sdk/resource"
-	sdktrace "go.opentelemetry.io/otel/sdk/trace"
-	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
 	"google.golang.org/grpc"
 )
 
 var addr = flag.String("addr", ":8081", "")
+var metricsAddr = flag.String("metricsAddr", ":9081", "")
+var storeBackend = flag.String("store", "memory", "user store backend, memory, file or sqlite")
+var storePath = flag.String("storePath", "", "path of the file store or the sqlite database, defaults to users.json or users.db")
+var traceCfg = telemetry.RegisterFlags(flag.CommandLine)
+var logLevel = logging.RegisterFlags(flag.CommandLine)
 
 func main() {
 	flag.Parse()
 
-	exporter, err := jaeger.New(jaeger.WithCollectorEndpoint(jaeger.WithEndpoint("http://localhost:14260/api/traces")))
+	logger, err := logging.New("user", *logLevel)
 	if err != nil {
 		log.Fatal(err)
 	}
-	// exporter, err := zipkin.New("http://localhost:9411/api/v2/spans")
-	// if err != nil {
-	// 	log.Fatal(err)
-	// }
-	tp := sdktrace.NewTracerProvider(
-		sdktrace.WithSampler(sdktrace.AlwaysSample()),
-		sdktrace.WithBatcher(exporter),
-		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceNameKey.String("user"))),
-	)
-	otel.SetTracerProvider(tp)
-	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	defer logger.Sync()
 
-	defer tp.Shutdown(context.Background())
+	traceCfg.ServiceName = "user"
+	shutdown, err := telemetry.Setup(context.Background(), *traceCfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer shutdown(context.Background())
 
-	store := user.NewStore()
-	store.Add(context.Background(), user.User{
-		Id:           "1",
-		Username:     "kasutaja",
-		PasswordHash: "$2a$10$cooR9Q2.ycvu6HEttewRi.cRK6DRR7gYS0POD.u89kzh8AgD0GG7.",
-	})
+	store, err := openStore(context.Background(), *storeBackend, *storePath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := seed(context.Background(), store); err != nil {
+		log.Fatal(err)
+	}
 
-	svc := user.NewService(store)
+	svc := user.NewService(store, logger)
 	userServer := &user.Server{
 		Svc: svc,
 	}
 
+	m := metrics.New()
+	go func() {
+		log.Fatal(m.ListenAndServe(*metricsAddr))
+	}()
+
 	srv := grpc.NewServer(
-		grpc.UnaryInterceptor(otelgrpc.UnaryServerInterceptor()),
-		grpc.StreamInterceptor(otelgrpc.StreamServerInterceptor()),
+		grpc.ChainUnaryInterceptor(otelgrpc.UnaryServerInterceptor(), m.UnaryServerInterceptor()),
+		grpc.ChainStreamInterceptor(otelgrpc.StreamServerInterceptor(), m.StreamServerInterceptor()),
+		clients.ServerKeepalive(),
 	)
 	lis, err := net.Listen("tcp", *addr)
 	if err != nil {
@@ -68,3 +75,41 @@ func main() {
 		log.Fatal(err)
 	}
 }
+
+func openStore(ctx context.Context, backend, path string) (user.Store, error) {
+	switch backend {
+	case "memory":
+		return user.NewMemoryStore(), nil
+	case "file":
+		return user.NewFileStore(pathOr(path, "users.json"))
+	case "sqlite":
+		db, err := user.OpenSQLite(pathOr(path, "users.db"))
+		if err != nil {
+			return nil, err
+		}
+		return user.NewSQLStore(ctx, db)
+	default:
+		return nil, fmt.Errorf("unknown store %q", backend)
+	}
+}
+
+func pathOr(path, def string) string {
+	if path == "" {
+		return def
+	}
+	return path
+}
+
+// seed adds the demo user unless the store already has it.
+func seed(ctx context.Context, store user.Store) error {
+	_, err := store.GetByName(ctx, "kasutaja")
+	if !errors.Is(err, user.ErrNotFound) {
+		return err
+	}
+
+	return store.Add(ctx, user.User{
+		Id:           "1",
+		Username:     "kasutaja",
+		PasswordHash: "$2a$10$cooR9Q2.ycvu6HEttewRi.cRK6DRR7gYS0POD.u89kzh8AgD0GG7.",
+	})
+}
@@ -1,28 +1,40 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
-	"fmt"
-	"log"
+	"errors"
+	"io"
 	"net/http"
+	"strings"
 	clients "toy/internal"
+	"toy/internal/logging"
+	"toy/schema/calculatorgrpc"
+
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type Service struct {
-	userClient          clients.UserClient
-	authenticatorClient clients.AuthenticatorClient
+	userClient          clients.UserService
+	authenticatorClient clients.AuthenticatorService
+	calculatorClient    clients.CalculatorService
 }
 
-func NewService(userClient clients.UserClient, authClient clients.AuthenticatorClient) Service {
-	return Service{userClient: userClient, authenticatorClient: authClient}
+func NewService(userClient clients.UserService, authClient clients.AuthenticatorService, calcClient clients.CalculatorService) Service {
+	return Service{userClient: userClient, authenticatorClient: authClient, calculatorClient: calcClient}
 }
 
 type Server struct {
-	svc Service
+	svc    Service
+	logger logging.Logger
 }
 
-func NewServer(svc Service) Server {
-	return Server{svc}
+func NewServer(svc Service, logger logging.Logger) Server {
+	return Server{svc: svc, logger: logger}
 }
 
 type AuthenticatorPasswordReq struct {
@@ -34,24 +46,260 @@ type AuthenticatorPasswordResponse struct {
 	Token string `json:"token"`
 }
 
+type CalculatorReq struct {
+	X int32 `json:"x"`
+	Y int32 `json:"y"`
+}
+
+type CalculatorResponse struct {
+	Value int32 `json:"value"`
+}
+
+type ExpressionReq struct {
+	Expression string `json:"expression"`
+}
+
+type EvaluateReq struct {
+	Operations []EvaluateOperation `json:"operations"`
+}
+
+// EvaluateOperation applies Op, one of add, substract, multiply or divide,
+// with Value to the running result.
+type EvaluateOperation struct {
+	Op    string `json:"op"`
+	Value int32  `json:"value"`
+}
+
+type EvaluateResponse struct {
+	Results []int32 `json:"results"`
+}
+
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
 func (s *Server) AuthenticatePassword(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
 	areq := AuthenticatorPasswordReq{}
 	if err := json.NewDecoder(r.Body).Decode(&areq); err != nil {
-		log.Fatal(err)
+		s.logger.Warn(ctx, "failed to decode request", zap.Error(err))
+		writeError(w, r, http.StatusBadRequest, "malformed request body", err)
+		return
 	}
 
-	ctx := r.Context()
 	resp, err := s.svc.authenticatorClient.AuthenticatePassword(ctx, areq.Username, areq.Password)
 	if err != nil {
-		fmt.Println(err)
+		s.logger.Error(ctx, "failed to authenticate password", zap.String("username", areq.Username), zap.Error(err))
+		writeGRPCError(w, r, err)
 		return
 	}
 
-	writeJSON(w, &AuthenticatorPasswordResponse{Token: resp.Token})
+	s.logger.Info(ctx, "authenticated password", zap.String("username", areq.Username))
+	writeJSON(w, http.StatusOK, &AuthenticatorPasswordResponse{Token: resp.Token})
+}
+
+type MeResponse struct {
+	Id       string `json:"id"`
+	Username string `json:"username"`
+	Role     string `json:"role"`
+}
+
+// Me returns the caller, it must be wrapped by Authenticate.
+func (s *Server) Me(w http.ResponseWriter, r *http.Request) {
+	claims, ok := ClaimsFromContext(r.Context())
+	if !ok {
+		writeError(w, r, http.StatusUnauthorized, errMissingToken.Error(), errMissingToken)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, &MeResponse{Id: claims.Subject, Username: claims.Username, Role: claims.Role})
+}
+
+func (s *Server) Add(w http.ResponseWriter, r *http.Request) {
+	s.calculate(w, r, "add", func(ctx context.Context, x, y int32) (int32, error) {
+		resp, err := s.svc.calculatorClient.Add(ctx, x, y)
+		return resp.GetValue(), err
+	})
+}
+
+func (s *Server) Substract(w http.ResponseWriter, r *http.Request) {
+	s.calculate(w, r, "substract", func(ctx context.Context, x, y int32) (int32, error) {
+		resp, err := s.svc.calculatorClient.Substract(ctx, x, y)
+		return resp.GetValue(), err
+	})
+}
+
+func (s *Server) Multiply(w http.ResponseWriter, r *http.Request) {
+	s.calculate(w, r, "multiply", func(ctx context.Context, x, y int32) (int32, error) {
+		resp, err := s.svc.calculatorClient.Multiply(ctx, x, y)
+		return resp.GetValue(), err
+	})
+}
+
+func (s *Server) Divide(w http.ResponseWriter, r *http.Request) {
+	s.calculate(w, r, "divide", func(ctx context.Context, x, y int32) (int32, error) {
+		resp, err := s.svc.calculatorClient.Divide(ctx, x, y)
+		return resp.GetValue(), err
+	})
+}
+
+func (s *Server) calculate(w http.ResponseWriter, r *http.Request, op string, call func(ctx context.Context, x, y int32) (int32, error)) {
+	ctx := r.Context()
+
+	creq := CalculatorReq{}
+	if err := json.NewDecoder(r.Body).Decode(&creq); err != nil {
+		s.logger.Warn(ctx, "failed to decode request", zap.Error(err))
+		writeError(w, r, http.StatusBadRequest, "malformed request body", err)
+		return
+	}
+
+	v, err := call(ctx, creq.X, creq.Y)
+	if err != nil {
+		s.logger.Error(ctx, "failed to calculate", zap.String("operation", op), zap.Int32("x", creq.X), zap.Int32("y", creq.Y), zap.Error(err))
+		writeGRPCError(w, r, err)
+		return
+	}
+
+	s.logger.Info(ctx, "calculated", zap.String("operation", op))
+	writeJSON(w, http.StatusOK, &CalculatorResponse{Value: v})
+}
+
+func (s *Server) EvaluateExpression(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	ereq := ExpressionReq{}
+	if err := json.NewDecoder(r.Body).Decode(&ereq); err != nil {
+		s.logger.Warn(ctx, "failed to decode request", zap.Error(err))
+		writeError(w, r, http.StatusBadRequest, "malformed request body", err)
+		return
+	}
+
+	resp, err := s.svc.calculatorClient.EvaluateExpression(ctx, ereq.Expression)
+	if err != nil {
+		s.logger.Error(ctx, "failed to evaluate expression", zap.String("expression", ereq.Expression), zap.Error(err))
+		writeGRPCError(w, r, err)
+		return
+	}
+
+	s.logger.Info(ctx, "evaluated expression", zap.String("expression", ereq.Expression))
+	writeJSON(w, http.StatusOK, &CalculatorResponse{Value: resp.Value})
+}
+
+// Evaluate streams the operations to the calculator and returns the running
+// result after each of them.
+func (s *Server) Evaluate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	ereq := EvaluateReq{}
+	if err := json.NewDecoder(r.Body).Decode(&ereq); err != nil {
+		s.logger.Warn(ctx, "failed to decode request", zap.Error(err))
+		writeError(w, r, http.StatusBadRequest, "malformed request body", err)
+		return
+	}
+
+	ops := make([]*calculatorgrpc.Operation, 0, len(ereq.Operations))
+	for _, o := range ereq.Operations {
+		op, ok := calculatorgrpc.Operation_Op_value["OP_"+strings.ToUpper(o.Op)]
+		if !ok || op == int32(calculatorgrpc.Operation_OP_UNSPECIFIED) {
+			writeError(w, r, http.StatusBadRequest, "unknown operation "+o.Op, errors.New("unknown operation"))
+			return
+		}
+		ops = append(ops, &calculatorgrpc.Operation{Op: calculatorgrpc.Operation_Op(op), Value: o.Value})
+	}
+
+	results, err := s.evaluate(ctx, ops)
+	if err != nil {
+		s.logger.Error(ctx, "failed to evaluate", zap.Int("operations", len(ops)), zap.Error(err))
+		writeGRPCError(w, r, err)
+		return
+	}
+
+	s.logger.Info(ctx, "evaluated", zap.Int("operations", len(ops)))
+	writeJSON(w, http.StatusOK, &EvaluateResponse{Results: results})
+}
+
+func (s *Server) evaluate(ctx context.Context, ops []*calculatorgrpc.Operation) ([]int32, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := s.svc.calculatorClient.Evaluate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Operations are sent while the results are received so that neither
+	// side blocks on a full stream window. Send errors surface in Recv.
+	go func() {
+		for _, op := range ops {
+			if err := stream.Send(op); err != nil {
+				return
+			}
+		}
+		stream.CloseSend()
+	}()
+
+	results := make([]int32, 0, len(ops))
+	for {
+		v, err := stream.Recv()
+		if err == io.EOF {
+			return results, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, v.Value)
+	}
+}
+
+// httpStatusFromCode maps the status code of a failed RPC to the HTTP status
+// returned to the caller.
+func httpStatusFromCode(code grpccodes.Code) int {
+	switch code {
+	case grpccodes.InvalidArgument:
+		return http.StatusBadRequest
+	case grpccodes.Unauthenticated:
+		return http.StatusUnauthorized
+	case grpccodes.PermissionDenied:
+		return http.StatusForbidden
+	case grpccodes.NotFound:
+		return http.StatusNotFound
+	case grpccodes.Unavailable:
+		return http.StatusServiceUnavailable
+	case grpccodes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func writeGRPCError(w http.ResponseWriter, r *http.Request, err error) {
+	st := status.Convert(err)
+	code := httpStatusFromCode(st.Code())
+
+	// Server side failures are described generically so that internal
+	// details such as backend addresses do not leak to the caller.
+	msg := st.Message()
+	if code >= http.StatusInternalServerError {
+		msg = http.StatusText(code)
+	}
+
+	writeError(w, r, code, msg, err)
+}
+
+// writeError writes msg as a JSON error body and marks the request span as
+// failed with err.
+func writeError(w http.ResponseWriter, r *http.Request, code int, msg string, err error) {
+	span := trace.SpanFromContext(r.Context())
+	span.RecordError(err)
+	span.SetStatus(codes.Error, msg)
+
+	writeJSON(w, code, &ErrorResponse{Error: msg})
 }
 
-func writeJSON(w http.ResponseWriter, v interface{}) {
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
 	b, _ := json.Marshal(v)
 	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	w.WriteHeader(code)
 	w.Write(b)
 }
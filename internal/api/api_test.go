@@ -0,0 +1,188 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"toy/internal/authenticator"
+	"toy/internal/calculator"
+	"toy/internal/clientstest"
+	"toy/internal/jwt"
+	"toy/internal/logging"
+	"toy/schema/usergrpc"
+
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// newTestServer serves the authenticator, backed by a fake user service,
+// and the calculator over bufconn, and routes to them as cmd/api does.
+func newTestServer(t *testing.T) http.Handler {
+	users := clientstest.NewUserService()
+	users.Add(&usergrpc.User{Id: "42", Username: "kasutaja"}, "parool")
+
+	a := authenticator.NewAuthenticator(users, jwt.NewHS256Wrapper("secret"), logging.NewNop())
+	authClient := clientstest.StartAuthenticatorServer(t, &authenticator.Server{A: a})
+	calcClient := clientstest.StartCalculatorServer(t, &calculator.Server{Svc: calculator.NewService(logging.NewNop())})
+
+	srv := NewServer(NewService(users, authClient, calcClient), logging.NewNop())
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/auth", srv.AuthenticatePassword)
+	mux.Handle("/me", srv.Authenticate(http.HandlerFunc(srv.Me)))
+	mux.HandleFunc("/calculator/divide", srv.Divide)
+	return mux
+}
+
+func do(t *testing.T, h http.Handler, path, token string, body interface{}, v interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+
+	b, err := json.Marshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(b))
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	if v != nil {
+		if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+			t.Fatalf("failed to decode %q: %v", w.Body.String(), err)
+		}
+	}
+	return w
+}
+
+func login(t *testing.T, h http.Handler) string {
+	t.Helper()
+
+	var resp AuthenticatorPasswordResponse
+	if w := do(t, h, "/auth", "", AuthenticatorPasswordReq{Username: "kasutaja", Password: "parool"}, &resp); w.Code != http.StatusOK {
+		t.Fatalf("login failed with %d: %s", w.Code, w.Body.String())
+	}
+	return resp.Token
+}
+
+func TestAuthenticatePassword(t *testing.T) {
+	h := newTestServer(t)
+
+	tests := []struct {
+		name     string
+		username string
+		password string
+		want     int
+	}{
+		{name: "success", username: "kasutaja", password: "parool", want: http.StatusOK},
+		{name: "unknown user", username: "tundmatu", password: "parool", want: http.StatusUnauthorized},
+		{name: "wrong password", username: "kasutaja", password: "vale", want: http.StatusUnauthorized},
+		{name: "missing password", username: "kasutaja", want: http.StatusBadRequest},
+	}
+
+	var messages []string
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var resp struct {
+				AuthenticatorPasswordResponse
+				ErrorResponse
+			}
+			w := do(t, h, "/auth", "", AuthenticatorPasswordReq{Username: tt.username, Password: tt.password}, &resp)
+			if w.Code != tt.want {
+				t.Fatalf("got status %d, want %d: %s", w.Code, tt.want, w.Body.String())
+			}
+			if tt.want == http.StatusOK && resp.Token == "" {
+				t.Error("got no token")
+			}
+			if tt.want == http.StatusUnauthorized {
+				messages = append(messages, resp.Error)
+			}
+		})
+	}
+
+	if len(messages) != 2 || messages[0] != messages[1] {
+		t.Errorf("got error messages %q, want the same message for an unknown user and a wrong password", messages)
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	h := newTestServer(t)
+	token := login(t, h)
+
+	var me MeResponse
+	if w := do(t, h, "/me", token, nil, &me); w.Code != http.StatusOK {
+		t.Fatalf("got status %d: %s", w.Code, w.Body.String())
+	}
+	if me.Id != "42" || me.Username != "kasutaja" {
+		t.Errorf("got %+v, want kasutaja with id 42", me)
+	}
+
+	tests := []struct {
+		name       string
+		token      string
+		wantHeader string
+	}{
+		{name: "missing token", wantHeader: "Bearer"},
+		{name: "invalid token", token: "not-a-token", wantHeader: `Bearer error="invalid_token"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := do(t, h, "/me", tt.token, nil, nil)
+			if w.Code != http.StatusUnauthorized {
+				t.Errorf("got status %d, want 401", w.Code)
+			}
+			if got := w.Header().Get("WWW-Authenticate"); got != tt.wantHeader {
+				t.Errorf("got WWW-Authenticate %q, want %q", got, tt.wantHeader)
+			}
+		})
+	}
+}
+
+func TestCalculate(t *testing.T) {
+	h := newTestServer(t)
+
+	var resp CalculatorResponse
+	if w := do(t, h, "/calculator/divide", "", CalculatorReq{X: 7, Y: 2}, &resp); w.Code != http.StatusOK {
+		t.Fatalf("got status %d: %s", w.Code, w.Body.String())
+	}
+	if resp.Value != 3 {
+		t.Errorf("got %d, want 3", resp.Value)
+	}
+
+	if w := do(t, h, "/calculator/divide", "", CalculatorReq{X: 7}, nil); w.Code != http.StatusBadRequest {
+		t.Errorf("got status %d for a division by zero, want 400", w.Code)
+	}
+}
+
+func TestWriteGRPCError(t *testing.T) {
+	tests := []struct {
+		err     error
+		want    int
+		wantMsg string
+	}{
+		{err: status.Error(grpccodes.NotFound, "user not found"), want: http.StatusNotFound, wantMsg: "user not found"},
+		{err: status.Error(grpccodes.Unauthenticated, "invalid token"), want: http.StatusUnauthorized, wantMsg: "invalid token"},
+		{err: status.Error(grpccodes.Unavailable, "dial tcp 10.0.0.1:8082"), want: http.StatusServiceUnavailable, wantMsg: "Service Unavailable"},
+		{err: status.Error(grpccodes.Internal, "boom"), want: http.StatusInternalServerError, wantMsg: "Internal Server Error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(status.Code(tt.err).String(), func(t *testing.T) {
+			w := httptest.NewRecorder()
+			writeGRPCError(w, httptest.NewRequest(http.MethodGet, "/", nil), tt.err)
+
+			var resp ErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if w.Code != tt.want || resp.Error != tt.wantMsg {
+				t.Errorf("got %d %q, want %d %q", w.Code, resp.Error, tt.want, tt.wantMsg)
+			}
+		})
+	}
+}
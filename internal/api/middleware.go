@@ -0,0 +1,68 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"toy/schema/authenticatorgrpc"
+
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var errMissingToken = errors.New("missing bearer token")
+
+type claimsKey struct{}
+
+// ClaimsFromContext returns the claims of the caller stored by Authenticate.
+func ClaimsFromContext(ctx context.Context) (*authenticatorgrpc.UserClaims, bool) {
+	claims, ok := ctx.Value(claimsKey{}).(*authenticatorgrpc.UserClaims)
+	return claims, ok
+}
+
+// Authenticate only lets requests with a valid Bearer token through to next.
+// The token is validated by the authenticator, its claims are stored in the
+// request context and recorded as enduser attributes on the request span.
+func (s *Server) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		token, ok := bearerToken(r)
+		if !ok {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeError(w, r, http.StatusUnauthorized, errMissingToken.Error(), errMissingToken)
+			return
+		}
+
+		resp, err := s.svc.authenticatorClient.ValidateToken(ctx, token)
+		if err != nil {
+			s.logger.Warn(ctx, "failed to validate token", zap.Error(err))
+			if status.Code(err) == grpccodes.Unauthenticated {
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			}
+			writeGRPCError(w, r, err)
+			return
+		}
+
+		claims := resp.Claims
+		trace.SpanFromContext(ctx).SetAttributes(
+			semconv.EnduserIDKey.String(claims.GetSubject()),
+			semconv.EnduserRoleKey.String(claims.GetRole()),
+		)
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(ctx, claimsKey{}, claims)))
+	})
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	const prefix = "Bearer "
+	h := r.Header.Get("Authorization")
+	if len(h) <= len(prefix) || !strings.EqualFold(h[:len(prefix)], prefix) {
+		return "", false
+	}
+	return strings.TrimSpace(h[len(prefix):]), true
+}
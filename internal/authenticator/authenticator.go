@@ -4,13 +4,18 @@ import (
 	"context"
 	"errors"
 	clients "toy/internal"
-	credentials "toy/internal/credentials"
 	"toy/internal/jwt"
+	"toy/internal/logging"
 	authenticatorgrpc "toy/schema/authenticatorgrpc"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
 	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type Server struct {
@@ -21,23 +26,48 @@ type Server struct {
 func (s *Server) AuthenticatePassword(ctx context.Context, req *authenticatorgrpc.AuthenticatePasswordReq) (*authenticatorgrpc.AuthenticatePasswordResponse, error) {
 	jwt, err := s.A.AuthenticatePassword(ctx, req)
 	if err != nil {
-		return nil, err
+		return nil, toStatus(err)
 	}
 
 	return &authenticatorgrpc.AuthenticatePasswordResponse{Token: jwt}, nil
 }
 
+func (s *Server) ValidateToken(ctx context.Context, req *authenticatorgrpc.ValidateTokenReq) (*authenticatorgrpc.ValidateTokenResponse, error) {
+	claims, err := s.A.ValidateToken(ctx, req.Token)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	uc := &authenticatorgrpc.UserClaims{
+		Username: claims.Username,
+		Role:     claims.Role,
+	}
+	if rc := claims.RegisteredClaims; rc != nil {
+		uc.Subject = rc.Subject
+		if rc.IssuedAt != nil {
+			uc.IssuedAt = rc.IssuedAt.Unix()
+		}
+		if rc.ExpiresAt != nil {
+			uc.ExpiresAt = rc.ExpiresAt.Unix()
+		}
+	}
+
+	return &authenticatorgrpc.ValidateTokenResponse{Claims: uc}, nil
+}
+
 type Authenticator struct {
-	userClient clients.UserClient
+	userClient clients.UserService
 	jwtWrapper jwt.Wrapper
 	tracer     trace.Tracer
+	logger     logging.Logger
 }
 
-func NewAuthenticator(userClient clients.UserClient, jwtWrapper jwt.Wrapper) Authenticator {
+func NewAuthenticator(userClient clients.UserService, jwtWrapper jwt.Wrapper, logger logging.Logger) Authenticator {
 	return Authenticator{
 		userClient: userClient,
 		jwtWrapper: jwtWrapper,
 		tracer:     otel.GetTracerProvider().Tracer("authenticator-service"),
+		logger:     logger,
 	}
 }
 
@@ -49,32 +79,74 @@ func (a Authenticator) AuthenticatePassword(ctx context.Context, req *authentica
 	ctxSpan, span := a.tracer.Start(ctx, "AuthenticatePassword")
 	defer span.End()
 
-	user, err := a.userClient.GetUserByName(ctxSpan, name)
-	if err != nil {
-		return "", err
+	if name == "" || password == "" {
+		span.RecordError(ErrMissingCredentials)
+		span.SetStatus(codes.Error, "missing credentials")
+		return "", ErrMissingCredentials
 	}
 
-	hashedPassword := user.User.PasswordHash
-
-	_, passwordMatchSpan := a.tracer.Start(ctxSpan, "password_match")
-	valid, err := credentials.CompareBCrypt(password, hashedPassword)
+	resp, err := a.userClient.VerifyPassword(ctxSpan, name, password)
 	if err != nil {
+		if status.Code(err) == grpccodes.NotFound {
+			err = ErrUserNotFound
+		}
+		a.logger.Warn(ctxSpan, "failed to verify password", zap.String("username", name), zap.Error(err))
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to verify password")
 		return "", err
 	}
-	passwordMatchSpan.End()
 
-	span.SetAttributes(attribute.Bool("password_match", valid))
+	span.SetAttributes(attribute.Bool("password_match", resp.Match))
 
-	if !valid {
-		return "", errors.New("pass mismatch")
+	if !resp.Match {
+		a.logger.Info(ctxSpan, "password mismatch", zap.String("username", name))
+		span.RecordError(ErrPasswordMismatch)
+		span.SetStatus(codes.Error, "password mismatch")
+		return "", ErrPasswordMismatch
 	}
 
 	claims := jwt.NewUserClaims(name, "role")
+	if resp.User != nil {
+		claims.Subject = resp.User.Id
+	}
 
 	token, err := a.jwtWrapper.Encode(claims)
 	if err != nil {
+		a.logger.Error(ctxSpan, "failed to encode token", zap.String("username", name), zap.Error(err))
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to encode token")
 		return "", err
 	}
 
+	a.logger.Info(ctxSpan, "authenticated password", zap.String("username", name))
 	return token, nil
 }
+
+// ValidateToken verifies token and returns its claims.
+func (a Authenticator) ValidateToken(ctx context.Context, token string) (jwt.UserClaims, error) {
+	ctxSpan, span := a.tracer.Start(ctx, "ValidateToken")
+	defer span.End()
+
+	if token == "" {
+		span.RecordError(ErrMissingToken)
+		span.SetStatus(codes.Error, "missing token")
+		return jwt.UserClaims{}, ErrMissingToken
+	}
+
+	claims, err := a.jwtWrapper.DecodeUserClaims(token)
+	if err != nil {
+		a.logger.Info(ctxSpan, "invalid token", zap.Error(err))
+		if errors.Is(err, jwt.ErrJWTExpired) {
+			err = ErrTokenExpired
+		} else {
+			err = ErrInvalidToken
+		}
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return jwt.UserClaims{}, err
+	}
+
+	span.SetAttributes(semconv.EnduserIDKey.String(claims.Subject), semconv.EnduserRoleKey.String(claims.Role))
+	a.logger.Debug(ctxSpan, "validated token", zap.String("username", claims.Username))
+	return claims, nil
+}
@@ -0,0 +1,137 @@
+package authenticator
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"toy/internal/clientstest"
+	"toy/internal/jwt"
+	"toy/internal/logging"
+	"toy/schema/authenticatorgrpc"
+	"toy/schema/usergrpc"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func newTestAuthenticator() (Authenticator, *clientstest.UserService) {
+	users := clientstest.NewUserService()
+	users.Add(&usergrpc.User{Id: "42", Username: "kasutaja"}, "parool")
+	return NewAuthenticator(users, jwt.NewHS256Wrapper("secret"), logging.NewNop()), users
+}
+
+func TestAuthenticatePassword(t *testing.T) {
+	a, _ := newTestAuthenticator()
+
+	tests := []struct {
+		name     string
+		username string
+		password string
+		wantErr  error
+	}{
+		{name: "success", username: "kasutaja", password: "parool"},
+		{name: "unknown user", username: "tundmatu", password: "parool", wantErr: ErrUserNotFound},
+		{name: "wrong password", username: "kasutaja", password: "vale", wantErr: ErrPasswordMismatch},
+		{name: "missing password", username: "kasutaja", wantErr: ErrMissingCredentials},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := a.AuthenticatePassword(context.Background(), &authenticatorgrpc.AuthenticatePasswordReq{Username: tt.username, Password: tt.password})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			claims, err := a.ValidateToken(context.Background(), token)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if claims.Username != "kasutaja" || claims.Subject != "42" {
+				t.Errorf("got claims for %q with subject %q", claims.Username, claims.Subject)
+			}
+		})
+	}
+}
+
+func TestAuthenticatePasswordUserServiceError(t *testing.T) {
+	a, users := newTestAuthenticator()
+	users.Err = status.Error(grpccodes.Unavailable, "connection refused")
+
+	_, err := a.AuthenticatePassword(context.Background(), &authenticatorgrpc.AuthenticatePasswordReq{Username: "kasutaja", Password: "parool"})
+	if got := status.Code(toStatus(err)); got != grpccodes.Unavailable {
+		t.Errorf("got code %s, want Unavailable", got)
+	}
+}
+
+func TestServer(t *testing.T) {
+	a, _ := newTestAuthenticator()
+	client := clientstest.StartAuthenticatorServer(t, &Server{A: a})
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		username string
+		password string
+		want     grpccodes.Code
+	}{
+		{name: "success", username: "kasutaja", password: "parool", want: grpccodes.OK},
+		{name: "unknown user", username: "tundmatu", password: "parool", want: grpccodes.Unauthenticated},
+		{name: "wrong password", username: "kasutaja", password: "vale", want: grpccodes.Unauthenticated},
+		{name: "missing password", username: "kasutaja", want: grpccodes.InvalidArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := client.AuthenticatePassword(ctx, tt.username, tt.password)
+			if got := status.Code(err); got != tt.want {
+				t.Fatalf("got code %s, want %s: %v", got, tt.want, err)
+			}
+			if err != nil {
+				return
+			}
+
+			vresp, err := client.ValidateToken(ctx, resp.Token)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := vresp.Claims.GetUsername(); got != tt.username {
+				t.Errorf("got claims for %q, want %q", got, tt.username)
+			}
+		})
+	}
+
+	if _, err := client.ValidateToken(ctx, "not-a-token"); status.Code(err) != grpccodes.Unauthenticated {
+		t.Errorf("got %v for an invalid token, want Unauthenticated", err)
+	}
+}
+
+// TestServerHidesUnknownUsers checks that an unknown user cannot be told
+// apart from a wrong password.
+func TestServerHidesUnknownUsers(t *testing.T) {
+	a, _ := newTestAuthenticator()
+	client := clientstest.StartAuthenticatorServer(t, &Server{A: a})
+
+	_, unknown := client.AuthenticatePassword(context.Background(), "tundmatu", "parool")
+	_, mismatch := client.AuthenticatePassword(context.Background(), "kasutaja", "vale")
+
+	su, sm := status.Convert(unknown), status.Convert(mismatch)
+	if su.Message() != sm.Message() {
+		t.Errorf("got messages %q and %q, want the same message", su.Message(), sm.Message())
+	}
+	if ru, rm := reason(su), reason(sm); ru != "INVALID_CREDENTIALS" || ru != rm {
+		t.Errorf("got reasons %q and %q, want INVALID_CREDENTIALS", ru, rm)
+	}
+}
+
+func reason(st *status.Status) string {
+	for _, d := range st.Details() {
+		if info, ok := d.(*errdetails.ErrorInfo); ok {
+			return info.Reason
+		}
+	}
+	return ""
+}
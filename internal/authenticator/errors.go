@@ -0,0 +1,38 @@
+package authenticator
+
+import (
+	"errors"
+	"toy/internal/grpcerr"
+
+	grpccodes "google.golang.org/grpc/codes"
+)
+
+const errorDomain = "authenticator.toy"
+
+// invalidCredentials is the message returned for both an unknown user and a
+// wrong password, so that callers cannot probe which usernames exist.
+const invalidCredentials = "invalid username or password"
+
+var (
+	ErrMissingCredentials = errors.New("username and password are required")
+	ErrUserNotFound       = errors.New("user not found")
+	ErrPasswordMismatch   = errors.New("password mismatch")
+	ErrMissingToken       = errors.New("token is required")
+	ErrInvalidToken       = errors.New("invalid token")
+	ErrTokenExpired       = errors.New("token expired")
+)
+
+// errorMappings report ErrUserNotFound and ErrPasswordMismatch identically,
+// their difference is only kept in logs and spans.
+var errorMappings = []grpcerr.Mapping{
+	{Err: ErrMissingCredentials, Code: grpccodes.InvalidArgument, Reason: "MISSING_CREDENTIALS"},
+	{Err: ErrUserNotFound, Code: grpccodes.Unauthenticated, Reason: "INVALID_CREDENTIALS", Message: invalidCredentials},
+	{Err: ErrPasswordMismatch, Code: grpccodes.Unauthenticated, Reason: "INVALID_CREDENTIALS", Message: invalidCredentials},
+	{Err: ErrMissingToken, Code: grpccodes.Unauthenticated, Reason: "MISSING_TOKEN"},
+	{Err: ErrInvalidToken, Code: grpccodes.Unauthenticated, Reason: "INVALID_TOKEN"},
+	{Err: ErrTokenExpired, Code: grpccodes.Unauthenticated, Reason: "TOKEN_EXPIRED"},
+}
+
+func toStatus(err error) error {
+	return grpcerr.Status(err, errorDomain, errorMappings)
+}
@@ -0,0 +1,174 @@
+package breaker
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var ErrOpen = errors.New("circuit breaker is open")
+
+type State int
+
+const (
+	StateClosed State = iota
+	StateOpen
+	StateHalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+type Config struct {
+	// FailureThreshold is the number of consecutive failures that opens the
+	// breaker.
+	FailureThreshold int
+	// OpenTimeout is how long the breaker stays open before letting trial
+	// requests through.
+	OpenTimeout time.Duration
+	// HalfOpenRequests is the number of concurrent trial requests allowed
+	// while half-open.
+	HalfOpenRequests int
+}
+
+func DefaultConfig() Config {
+	return Config{
+		FailureThreshold: 5,
+		OpenTimeout:      10 * time.Second,
+		HalfOpenRequests: 1,
+	}
+}
+
+// Breaker is a circuit breaker. It is closed while calls succeed, opens after
+// FailureThreshold consecutive failures and rejects calls until OpenTimeout
+// has passed. It is then half-open: a trial call closes it again when it
+// succeeds, or reopens it when it fails.
+//
+// Every state change starts a new generation, and outcomes of calls allowed
+// in an earlier generation are ignored, so that only the trial calls count
+// while half-open.
+type Breaker struct {
+	name string
+	cfg  Config
+
+	mu         sync.Mutex
+	state      State
+	generation uint64
+	failures   int
+	openedAt   time.Time
+	trials     int
+}
+
+func New(name string, cfg Config) *Breaker {
+	if cfg.HalfOpenRequests < 1 {
+		cfg.HalfOpenRequests = 1
+	}
+	return &Breaker{name: name, cfg: cfg}
+}
+
+func (b *Breaker) Name() string {
+	return b.name
+}
+
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.currentState(time.Now())
+}
+
+// Allow reports whether a call may proceed, returning ErrOpen when it may
+// not. Every allowed call must be followed by Done with the returned
+// generation and its outcome.
+func (b *Breaker) Allow() (uint64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.currentState(time.Now()) {
+	case StateOpen:
+		return 0, ErrOpen
+	case StateHalfOpen:
+		if b.trials >= b.cfg.HalfOpenRequests {
+			return 0, ErrOpen
+		}
+		b.trials++
+	}
+	return b.generation, nil
+}
+
+// Done records the outcome of a call allowed in generation.
+func (b *Breaker) Done(generation uint64, success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	state := b.currentState(time.Now())
+	if generation != b.generation {
+		return
+	}
+
+	switch state {
+	case StateClosed:
+		if success {
+			b.failures = 0
+			return
+		}
+		b.failures++
+		if b.failures >= b.cfg.FailureThreshold {
+			b.open()
+		}
+	case StateHalfOpen:
+		b.trials--
+		if success {
+			b.setState(StateClosed)
+			return
+		}
+		b.open()
+	}
+}
+
+// currentState moves an open breaker to half-open once OpenTimeout has
+// passed. It must be called with b.mu held.
+func (b *Breaker) currentState(now time.Time) State {
+	if b.state == StateOpen && now.Sub(b.openedAt) >= b.cfg.OpenTimeout {
+		b.setState(StateHalfOpen)
+	}
+	return b.state
+}
+
+// open must be called with b.mu held.
+func (b *Breaker) open() {
+	b.setState(StateOpen)
+	b.openedAt = time.Now()
+}
+
+// setState starts a new generation in state. It must be called with b.mu
+// held.
+func (b *Breaker) setState(state State) {
+	b.state = state
+	b.generation++
+	b.failures = 0
+	b.trials = 0
+}
+
+// Collector exposes the state of the breaker as the circuit_breaker_state
+// gauge: 0 closed, 1 open and 2 half-open.
+func (b *Breaker) Collector() prometheus.Collector {
+	return prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name:        "circuit_breaker_state",
+		Help:        "State of the circuit breaker, 0 closed, 1 open, 2 half-open.",
+		ConstLabels: prometheus.Labels{"name": b.name},
+	}, func() float64 {
+		return float64(b.State())
+	})
+}
@@ -0,0 +1,72 @@
+package breaker
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func allow(t *testing.T, b *Breaker) uint64 {
+	t.Helper()
+	generation, err := b.Allow()
+	if err != nil {
+		t.Fatalf("call rejected in state %s: %v", b.State(), err)
+	}
+	return generation
+}
+
+func expectState(t *testing.T, b *Breaker, want State) {
+	t.Helper()
+	if got := b.State(); got != want {
+		t.Fatalf("got state %s, want %s", got, want)
+	}
+}
+
+func TestBreaker(t *testing.T) {
+	b := New("test", Config{FailureThreshold: 2, OpenTimeout: 10 * time.Millisecond, HalfOpenRequests: 1})
+
+	b.Done(allow(t, b), false)
+	expectState(t, b, StateClosed)
+	b.Done(allow(t, b), false)
+	expectState(t, b, StateOpen)
+
+	if _, err := b.Allow(); !errors.Is(err, ErrOpen) {
+		t.Fatalf("got %v while open, want ErrOpen", err)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	expectState(t, b, StateHalfOpen)
+
+	trial := allow(t, b)
+	if _, err := b.Allow(); !errors.Is(err, ErrOpen) {
+		t.Fatalf("got %v for a second trial, want ErrOpen", err)
+	}
+	b.Done(trial, false)
+	expectState(t, b, StateOpen)
+
+	time.Sleep(10 * time.Millisecond)
+	b.Done(allow(t, b), true)
+	expectState(t, b, StateClosed)
+}
+
+func TestBreakerIgnoresCallsOfEarlierGenerations(t *testing.T) {
+	b := New("test", Config{FailureThreshold: 1, OpenTimeout: 10 * time.Millisecond, HalfOpenRequests: 1})
+
+	// slow is allowed while closed and completes once the breaker is
+	// half-open.
+	slow := allow(t, b)
+	b.Done(allow(t, b), false)
+	expectState(t, b, StateOpen)
+
+	time.Sleep(10 * time.Millisecond)
+	trial := allow(t, b)
+
+	b.Done(slow, true)
+	expectState(t, b, StateHalfOpen)
+	if _, err := b.Allow(); !errors.Is(err, ErrOpen) {
+		t.Fatalf("got %v, want the slow call to leave the trial slot taken", err)
+	}
+
+	b.Done(trial, false)
+	expectState(t, b, StateOpen)
+}
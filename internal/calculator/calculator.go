@@ -0,0 +1,281 @@
+package calculator
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"toy/internal/logging"
+	"toy/schema/calculatorgrpc"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+type Server struct {
+	calculatorgrpc.UnimplementedCalculatorServer
+	Svc service
+}
+
+func (s *Server) Add(ctx context.Context, req *calculatorgrpc.Values) (*calculatorgrpc.AddValue, error) {
+	v, err := s.Svc.Add(ctx, req.X, req.Y)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &calculatorgrpc.AddValue{Value: v}, nil
+}
+
+func (s *Server) Substract(ctx context.Context, req *calculatorgrpc.Values) (*calculatorgrpc.SubstractValue, error) {
+	v, err := s.Svc.Substract(ctx, req.X, req.Y)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &calculatorgrpc.SubstractValue{Value: v}, nil
+}
+
+func (s *Server) Multiply(ctx context.Context, req *calculatorgrpc.Values) (*calculatorgrpc.MultiplyValue, error) {
+	v, err := s.Svc.Multiply(ctx, req.X, req.Y)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &calculatorgrpc.MultiplyValue{Value: v}, nil
+}
+
+func (s *Server) Divide(ctx context.Context, req *calculatorgrpc.Values) (*calculatorgrpc.DivideValue, error) {
+	v, err := s.Svc.Divide(ctx, req.X, req.Y)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &calculatorgrpc.DivideValue{Value: v}, nil
+}
+
+// Evaluate applies the received operations to a running result starting at
+// 0. Every operation is traced as a child of the stream span. The stream
+// ends at the first operation that fails or is unknown.
+func (s *Server) Evaluate(stream calculatorgrpc.Calculator_EvaluateServer) error {
+	ctx := stream.Context()
+
+	var result int32
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		op, ok := operations[req.Op]
+		if !ok {
+			return toStatus(fmt.Errorf("%w %s", ErrUnknownOperation, req.Op))
+		}
+
+		result, err = s.Svc.Calculate(ctx, op, result, req.Value)
+		if err != nil {
+			return toStatus(err)
+		}
+
+		if err := stream.Send(&calculatorgrpc.EvaluateValue{Value: result}); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Server) EvaluateExpression(ctx context.Context, req *calculatorgrpc.Expression) (*calculatorgrpc.ExpressionValue, error) {
+	v, err := s.Svc.EvaluateExpression(ctx, req.Expression)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &calculatorgrpc.ExpressionValue{Value: v}, nil
+}
+
+var operations = map[calculatorgrpc.Operation_Op]Operation{
+	calculatorgrpc.Operation_OP_ADD:       OperationAdd,
+	calculatorgrpc.Operation_OP_SUBSTRACT: OperationSubstract,
+	calculatorgrpc.Operation_OP_MULTIPLY:  OperationMultiply,
+	calculatorgrpc.Operation_OP_DIVIDE:    OperationDivide,
+}
+
+type Operation string
+
+const (
+	OperationAdd       Operation = "add"
+	OperationSubstract Operation = "substract"
+	OperationMultiply  Operation = "multiply"
+	OperationDivide    Operation = "divide"
+)
+
+type service struct {
+	tracer trace.Tracer
+	logger logging.Logger
+}
+
+func NewService(logger logging.Logger) service {
+	return service{
+		tracer: otel.GetTracerProvider().Tracer("calculator-service"),
+		logger: logger,
+	}
+}
+
+func (s service) Add(ctx context.Context, x, y int32) (int32, error) {
+	return s.Calculate(ctx, OperationAdd, x, y)
+}
+
+func (s service) Substract(ctx context.Context, x, y int32) (int32, error) {
+	return s.Calculate(ctx, OperationSubstract, x, y)
+}
+
+func (s service) Multiply(ctx context.Context, x, y int32) (int32, error) {
+	return s.Calculate(ctx, OperationMultiply, x, y)
+}
+
+func (s service) Divide(ctx context.Context, x, y int32) (int32, error) {
+	return s.Calculate(ctx, OperationDivide, x, y)
+}
+
+// Calculate traces op applied to x and y. The result is computed in int64 so
+// that results outside of the int32 range are reported as ErrOverflow instead
+// of wrapping around.
+func (s service) Calculate(ctx context.Context, op Operation, x, y int32) (int32, error) {
+	ctxSpan, span := s.tracer.Start(ctx, string(op))
+	span.SetAttributes(
+		attribute.String("calculator.operation", string(op)),
+		attribute.Int64("calculator.x", int64(x)),
+		attribute.Int64("calculator.y", int64(y)),
+	)
+	defer span.End()
+
+	result, err := compute(op, int64(x), int64(y))
+	if err == nil && (result > math.MaxInt32 || result < math.MinInt32) {
+		err = ErrOverflow
+	}
+	if err != nil {
+		s.logger.Warn(ctxSpan, "failed to calculate", zap.String("operation", string(op)), zap.Int32("x", x), zap.Int32("y", y), zap.Error(err))
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return 0, err
+	}
+
+	span.SetAttributes(attribute.Int64("calculator.result", result))
+	s.logger.Debug(ctxSpan, "calculated", zap.String("operation", string(op)), zap.Int64("result", result))
+	return int32(result), nil
+}
+
+// EvaluateExpression parses expr and evaluates its AST, tracing the
+// evaluation of every node as a child of the span of its parent node.
+func (s service) EvaluateExpression(ctx context.Context, expr string) (int32, error) {
+	ctxSpan, span := s.tracer.Start(ctx, "EvaluateExpression")
+	span.SetAttributes(attribute.String("calculator.expression", expr))
+	defer span.End()
+
+	node, err := s.parse(ctxSpan, expr)
+	if err != nil {
+		s.logger.Warn(ctxSpan, "failed to parse expression", zap.String("expression", expr), zap.Error(err))
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to parse expression")
+		return 0, err
+	}
+
+	v, err := s.eval(ctxSpan, node)
+	if err != nil {
+		s.logger.Warn(ctxSpan, "failed to evaluate expression", zap.String("expression", expr), zap.Error(err))
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to evaluate expression")
+		return 0, err
+	}
+
+	span.SetAttributes(attribute.Int64("calculator.result", int64(v)))
+	s.logger.Debug(ctxSpan, "evaluated expression", zap.String("expression", expr), zap.Int32("result", v))
+	return v, nil
+}
+
+func (s service) parse(ctx context.Context, expr string) (Node, error) {
+	_, span := s.tracer.Start(ctx, "parse")
+	defer span.End()
+
+	node, err := Parse(expr)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	span.SetAttributes(attribute.String("calculator.ast", node.String()))
+	return node, nil
+}
+
+func (s service) eval(ctx context.Context, node Node) (int32, error) {
+	switch n := node.(type) {
+	case Number:
+		_, span := s.tracer.Start(ctx, "number")
+		span.SetAttributes(attribute.Int64("calculator.result", int64(n.Value)))
+		span.End()
+		return n.Value, nil
+	case Negate:
+		return s.evalNode(ctx, "negate", n, func(ctx context.Context) (int64, error) {
+			x, err := s.eval(ctx, n.X)
+			return -int64(x), err
+		})
+	case Binary:
+		return s.evalNode(ctx, string(n.Op), n, func(ctx context.Context) (int64, error) {
+			x, err := s.eval(ctx, n.X)
+			if err != nil {
+				return 0, err
+			}
+			y, err := s.eval(ctx, n.Y)
+			if err != nil {
+				return 0, err
+			}
+			return compute(n.Op, int64(x), int64(y))
+		})
+	default:
+		return 0, ErrUnknownOperation
+	}
+}
+
+// evalNode traces the evaluation of node, whose operands are evaluated by fn
+// in child spans.
+func (s service) evalNode(ctx context.Context, name string, node Node, fn func(ctx context.Context) (int64, error)) (int32, error) {
+	ctxSpan, span := s.tracer.Start(ctx, name)
+	span.SetAttributes(attribute.String("calculator.expression", node.String()))
+	defer span.End()
+
+	result, err := fn(ctxSpan)
+	if err == nil && (result > math.MaxInt32 || result < math.MinInt32) {
+		err = ErrOverflow
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return 0, err
+	}
+
+	span.SetAttributes(attribute.Int64("calculator.result", result))
+	return int32(result), nil
+}
+
+func compute(op Operation, x, y int64) (int64, error) {
+	switch op {
+	case OperationAdd:
+		return x + y, nil
+	case OperationSubstract:
+		return x - y, nil
+	case OperationMultiply:
+		return x * y, nil
+	case OperationDivide:
+		if y == 0 {
+			return 0, ErrDivisionByZero
+		}
+		return x / y, nil
+	default:
+		return 0, ErrUnknownOperation
+	}
+}
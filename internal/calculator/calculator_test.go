@@ -0,0 +1,128 @@
+package calculator
+
+import (
+	"context"
+	"io"
+	"testing"
+	"toy/internal/clientstest"
+	"toy/internal/grpcerr"
+	"toy/internal/logging"
+	"toy/schema/calculatorgrpc"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestEvaluate(t *testing.T) {
+	add := calculatorgrpc.Operation_OP_ADD
+
+	tests := []struct {
+		name       string
+		ops        []*calculatorgrpc.Operation
+		want       []int32
+		wantCode   grpccodes.Code
+		wantReason string
+	}{
+		{
+			name: "running result",
+			ops: []*calculatorgrpc.Operation{
+				{Op: add, Value: 5},
+				{Op: calculatorgrpc.Operation_OP_MULTIPLY, Value: 4},
+				{Op: calculatorgrpc.Operation_OP_SUBSTRACT, Value: 8},
+				{Op: calculatorgrpc.Operation_OP_DIVIDE, Value: 3},
+			},
+			want: []int32{5, 20, 12, 4},
+		},
+		{
+			name:       "division by zero",
+			ops:        []*calculatorgrpc.Operation{{Op: add, Value: 1}, {Op: calculatorgrpc.Operation_OP_DIVIDE, Value: 0}, {Op: add, Value: 1}},
+			want:       []int32{1},
+			wantCode:   grpccodes.InvalidArgument,
+			wantReason: "DIVISION_BY_ZERO",
+		},
+		{
+			name:       "overflow",
+			ops:        []*calculatorgrpc.Operation{{Op: add, Value: 2147483647}, {Op: add, Value: 1}},
+			want:       []int32{2147483647},
+			wantCode:   grpccodes.InvalidArgument,
+			wantReason: "INT32_OVERFLOW",
+		},
+		{
+			name:       "unspecified operation",
+			ops:        []*calculatorgrpc.Operation{{Op: add, Value: 1}, {Value: 1}},
+			want:       []int32{1},
+			wantCode:   grpccodes.InvalidArgument,
+			wantReason: "UNKNOWN_OPERATION",
+		},
+		{
+			name:       "unknown operation",
+			ops:        []*calculatorgrpc.Operation{{Op: 42, Value: 1}},
+			wantCode:   grpccodes.InvalidArgument,
+			wantReason: "UNKNOWN_OPERATION",
+		},
+	}
+
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(tp)
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	client := clientstest.StartCalculatorServer(t, &Server{Svc: NewService(logging.NewNop())})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream, err := client.Evaluate(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// The server stops reading at the first error, so the
+			// operations after it may fail to send.
+			for _, op := range tt.ops {
+				if err := stream.Send(op); err != nil {
+					break
+				}
+			}
+			if err := stream.CloseSend(); err != nil {
+				t.Fatal(err)
+			}
+
+			var got []int32
+			for {
+				var resp *calculatorgrpc.EvaluateValue
+				if resp, err = stream.Recv(); err != nil {
+					break
+				}
+				got = append(got, resp.Value)
+			}
+			if err == io.EOF {
+				err = nil
+			}
+			if status.Code(err) != tt.wantCode || grpcerr.Reason(err) != tt.wantReason {
+				t.Errorf("got %v, want %s with %q", err, tt.wantCode, tt.wantReason)
+			}
+
+			// Unknown operations are rejected before they are traced.
+			for _, span := range exporter.GetSpans() {
+				if span.Name == "" {
+					t.Errorf("got a span without a name, attributes %v", span.Attributes)
+				}
+			}
+			exporter.Reset()
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("got results %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("got results %v, want %v", got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
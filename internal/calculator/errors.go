@@ -0,0 +1,29 @@
+package calculator
+
+import (
+	"errors"
+	"toy/internal/grpcerr"
+
+	grpccodes "google.golang.org/grpc/codes"
+)
+
+const errorDomain = "calculator.toy"
+
+var (
+	ErrOverflow         = errors.New("result overflows int32")
+	ErrDivisionByZero   = errors.New("division by zero")
+	ErrUnknownOperation = errors.New("unknown operation")
+	// ErrInvalidExpression is wrapped by the errors of Parse.
+	ErrInvalidExpression = errors.New("invalid expression")
+)
+
+var errorMappings = []grpcerr.Mapping{
+	{Err: ErrOverflow, Code: grpccodes.InvalidArgument, Reason: "INT32_OVERFLOW"},
+	{Err: ErrDivisionByZero, Code: grpccodes.InvalidArgument, Reason: "DIVISION_BY_ZERO"},
+	{Err: ErrUnknownOperation, Code: grpccodes.InvalidArgument, Reason: "UNKNOWN_OPERATION"},
+	{Err: ErrInvalidExpression, Code: grpccodes.InvalidArgument, Reason: "INVALID_EXPRESSION"},
+}
+
+func toStatus(err error) error {
+	return grpcerr.Status(err, errorDomain, errorMappings)
+}
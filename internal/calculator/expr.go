@@ -0,0 +1,223 @@
+package calculator
+
+import (
+	"fmt"
+	"strconv"
+)
+
+const (
+	// MaxExpressionLength bounds the size of the expressions Parse accepts.
+	MaxExpressionLength = 1024
+	maxDepth            = 64
+)
+
+// Node is a node of the AST of an expression.
+type Node interface {
+	String() string
+}
+
+// Number is an int32 literal, including the minus of a negative one.
+type Number struct {
+	Value int32
+}
+
+func (n Number) String() string {
+	return strconv.FormatInt(int64(n.Value), 10)
+}
+
+// Negate is a unary minus of anything but a literal.
+type Negate struct {
+	X Node
+}
+
+func (n Negate) String() string {
+	return "-" + n.X.String()
+}
+
+// Binary applies Op to X and Y.
+type Binary struct {
+	Op   Operation
+	X, Y Node
+}
+
+func (b Binary) String() string {
+	return "(" + b.X.String() + " " + symbols[b.Op] + " " + b.Y.String() + ")"
+}
+
+var symbols = map[Operation]string{
+	OperationAdd:       "+",
+	OperationSubstract: "-",
+	OperationMultiply:  "*",
+	OperationDivide:    "/",
+}
+
+var operators = map[byte]Operation{
+	'+': OperationAdd,
+	'-': OperationSubstract,
+	'*': OperationMultiply,
+	'/': OperationDivide,
+}
+
+// Parse parses an infix expression of int32 numbers, + - * / and parentheses
+// into its AST. * and / bind tighter than + and -, operators of the same
+// precedence are left associative. Errors wrap ErrInvalidExpression.
+func Parse(expr string) (Node, error) {
+	if len(expr) > MaxExpressionLength {
+		return nil, fmt.Errorf("%w: longer than %d bytes", ErrInvalidExpression, MaxExpressionLength)
+	}
+
+	p := &parser{src: expr}
+	n, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+
+	p.skipSpace()
+	if p.pos < len(p.src) {
+		return nil, p.errorf("unexpected %q", p.src[p.pos])
+	}
+	return n, nil
+}
+
+type parser struct {
+	src   string
+	pos   int
+	depth int
+}
+
+// expr parses term (("+" | "-") term)*.
+func (p *parser) expr() (Node, error) {
+	return p.binary(p.term, '+', '-')
+}
+
+// term parses unary (("*" | "/") unary)*.
+func (p *parser) term() (Node, error) {
+	return p.binary(p.unary, '*', '/')
+}
+
+func (p *parser) binary(operand func() (Node, error), ops ...byte) (Node, error) {
+	x, err := operand()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		c, ok := p.peek()
+		if !ok || (c != ops[0] && c != ops[1]) {
+			return x, nil
+		}
+		p.pos++
+
+		y, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		x = Binary{Op: operators[c], X: x, Y: y}
+	}
+}
+
+// unary parses "-" number | "-" unary | primary. The minus is part of a
+// number that follows it, so that -2147483648 does not overflow.
+func (p *parser) unary() (Node, error) {
+	if c, ok := p.peek(); ok && c == '-' {
+		minus := p.pos
+		p.pos++
+		if c, ok := p.peek(); ok && c >= '0' && c <= '9' {
+			return p.number(minus)
+		}
+
+		if err := p.enter(); err != nil {
+			return nil, err
+		}
+		defer p.leave()
+
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return Negate{X: x}, nil
+	}
+	return p.primary()
+}
+
+// primary parses number | "(" expr ")".
+func (p *parser) primary() (Node, error) {
+	c, ok := p.peek()
+	switch {
+	case !ok:
+		return nil, p.errorf("unexpected end of expression")
+	case c == '(':
+		p.pos++
+		if err := p.enter(); err != nil {
+			return nil, err
+		}
+		defer p.leave()
+
+		x, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		if c, ok := p.peek(); !ok || c != ')' {
+			return nil, p.errorf("missing )")
+		}
+		p.pos++
+		return x, nil
+	case c >= '0' && c <= '9':
+		return p.number(p.pos)
+	default:
+		return nil, p.errorf("unexpected %q", c)
+	}
+}
+
+// number parses the digits at pos, negated when start is the offset of a
+// minus before them.
+func (p *parser) number(start int) (Node, error) {
+	digits := p.pos
+	for p.pos < len(p.src) && p.src[p.pos] >= '0' && p.src[p.pos] <= '9' {
+		p.pos++
+	}
+
+	lit := p.src[digits:p.pos]
+	if p.src[start] == '-' {
+		lit = "-" + lit
+	}
+	v, err := strconv.ParseInt(lit, 10, 32)
+	if err != nil {
+		p.pos = start
+		return nil, p.errorf("number %s overflows int32", lit)
+	}
+	return Number{Value: int32(v)}, nil
+}
+
+// peek skips whitespace and returns the next byte without consuming it.
+func (p *parser) peek() (byte, bool) {
+	p.skipSpace()
+	if p.pos >= len(p.src) {
+		return 0, false
+	}
+	return p.src[p.pos], true
+}
+
+func (p *parser) skipSpace() {
+	for p.pos < len(p.src) && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t' || p.src[p.pos] == '\n' || p.src[p.pos] == '\r') {
+		p.pos++
+	}
+}
+
+// enter bounds the nesting of parentheses and unary minuses, which would
+// otherwise let a short expression exhaust the stack.
+func (p *parser) enter() error {
+	p.depth++
+	if p.depth > maxDepth {
+		return p.errorf("nested deeper than %d", maxDepth)
+	}
+	return nil
+}
+
+func (p *parser) leave() {
+	p.depth--
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s at offset %d", ErrInvalidExpression, fmt.Sprintf(format, args...), p.pos)
+}
@@ -0,0 +1,196 @@
+package calculator
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"toy/internal/logging"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		expr string
+		want Node
+	}{
+		{expr: "42", want: Number{42}},
+		{expr: "1+2*3", want: Binary{Op: OperationAdd, X: Number{1}, Y: Binary{Op: OperationMultiply, X: Number{2}, Y: Number{3}}}},
+		{expr: "1*2-3", want: Binary{Op: OperationSubstract, X: Binary{Op: OperationMultiply, X: Number{1}, Y: Number{2}}, Y: Number{3}}},
+		{expr: "(1+2)*3", want: Binary{Op: OperationMultiply, X: Binary{Op: OperationAdd, X: Number{1}, Y: Number{2}}, Y: Number{3}}},
+		{expr: "8/2/2", want: Binary{Op: OperationDivide, X: Binary{Op: OperationDivide, X: Number{8}, Y: Number{2}}, Y: Number{2}}},
+		{expr: "1-2-3", want: Binary{Op: OperationSubstract, X: Binary{Op: OperationSubstract, X: Number{1}, Y: Number{2}}, Y: Number{3}}},
+		{expr: "-3", want: Number{-3}},
+		{expr: "- 3", want: Number{-3}},
+		{expr: "--3", want: Negate{Number{-3}}},
+		{expr: "-(3)", want: Negate{Number{3}}},
+		{expr: "2*-3", want: Binary{Op: OperationMultiply, X: Number{2}, Y: Number{-3}}},
+		{expr: "1--3", want: Binary{Op: OperationSubstract, X: Number{1}, Y: Number{-3}}},
+		{expr: " 1 +\t2\r\n", want: Binary{Op: OperationAdd, X: Number{1}, Y: Number{2}}},
+		{expr: "2147483647", want: Number{math.MaxInt32}},
+		{expr: "-2147483648", want: Number{math.MinInt32}},
+		{expr: strings.Repeat("(", maxDepth) + "1" + strings.Repeat(")", maxDepth), want: Number{1}},
+	}
+
+	for _, tt := range tests {
+		name := tt.expr
+		if len(name) > 20 {
+			name = name[:20] + "..."
+		}
+		t.Run(name, func(t *testing.T) {
+			got, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+		want string
+	}{
+		{name: "empty", expr: "", want: "unexpected end of expression at offset 0"},
+		{name: "blank", expr: "  ", want: "unexpected end of expression at offset 2"},
+		{name: "missing operand", expr: "1 +", want: "unexpected end of expression at offset 3"},
+		{name: "missing )", expr: "(1+2", want: "missing ) at offset 4"},
+		{name: "extra )", expr: "1+2)", want: "unexpected ')' at offset 3"},
+		{name: "missing operator", expr: "1 2", want: "unexpected '2' at offset 2"},
+		{name: "unknown character", expr: "1+a", want: "unexpected 'a' at offset 2"},
+		{name: "overflow", expr: "1+2147483648", want: "number 2147483648 overflows int32 at offset 2"},
+		{name: "negative overflow", expr: "1+-2147483649", want: "number -2147483649 overflows int32 at offset 2"},
+		{name: "nested parentheses", expr: strings.Repeat("(", maxDepth+1) + "1" + strings.Repeat(")", maxDepth+1), want: "nested deeper than 64 at offset 65"},
+		// The last minus is part of the number, the others are nested.
+		{name: "nested minuses", expr: strings.Repeat("-", maxDepth+2) + "1", want: "nested deeper than 64 at offset 65"},
+		{name: "too long", expr: strings.Repeat("1+", MaxExpressionLength/2) + "1", want: "longer than 1024 bytes"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.expr)
+			if !errors.Is(err, ErrInvalidExpression) {
+				t.Fatalf("got %v, want ErrInvalidExpression", err)
+			}
+			if want := "invalid expression: " + tt.want; err.Error() != want {
+				t.Errorf("got %q, want %q", err, want)
+			}
+		})
+	}
+}
+
+func TestParseMaxExpressionLength(t *testing.T) {
+	expr := strings.Repeat("1+", MaxExpressionLength/2-1) + "12"
+	if len(expr) != MaxExpressionLength {
+		t.Fatalf("got a %d byte expression", len(expr))
+	}
+	if _, err := Parse(expr); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestEvaluateExpression(t *testing.T) {
+	tests := []struct {
+		expr    string
+		want    int32
+		wantErr error
+	}{
+		{expr: "1+2*3", want: 7},
+		{expr: "(1+2)*3", want: 9},
+		{expr: "8/2/2", want: 2},
+		{expr: "1-2-3", want: -4},
+		{expr: "-(2+3)*-2", want: 10},
+		{expr: "7/-2", want: -3},
+		{expr: "-2147483648", want: math.MinInt32},
+		{expr: "-2147483647-1", want: math.MinInt32},
+		{expr: "2147483647+1", wantErr: ErrOverflow},
+		{expr: "-(-2147483648)", wantErr: ErrOverflow},
+		{expr: "65536*65536", wantErr: ErrOverflow},
+		{expr: "1/(2-2)", wantErr: ErrDivisionByZero},
+		{expr: "1+", wantErr: ErrInvalidExpression},
+	}
+
+	svc := NewService(logging.NewNop())
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := svc.EvaluateExpression(context.Background(), tt.expr)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluateExpressionSpans(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{expr: "-3", want: "EvaluateExpression(parse number)"},
+		{expr: "-(1+2)*4", want: "EvaluateExpression(parse multiply(negate(add(number number)) number))"},
+		{expr: "1-2-3", want: "EvaluateExpression(parse substract(substract(number number) number))"},
+		// The evaluation stops at the first error.
+		{expr: "1/0+2", want: "EvaluateExpression(parse add(divide(number number)))"},
+		{expr: "1+", want: "EvaluateExpression(parse)"},
+	}
+
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(tp)
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	svc := NewService(logging.NewNop())
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			exporter.Reset()
+			svc.EvaluateExpression(context.Background(), tt.expr)
+
+			if got := spanTree(exporter.GetSpans()); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// spanTree renders the names of spans as name(children...), starting from
+// the root span. Siblings are in the order they ended, which is the order
+// they ran in.
+func spanTree(spans tracetest.SpanStubs) string {
+	children := map[trace.SpanID][]tracetest.SpanStub{}
+	var root tracetest.SpanStub
+	for _, span := range spans {
+		if !span.Parent.IsValid() {
+			root = span
+			continue
+		}
+		parent := span.Parent.SpanID()
+		children[parent] = append(children[parent], span)
+	}
+
+	var render func(span tracetest.SpanStub) string
+	render = func(span tracetest.SpanStub) string {
+		kids := children[span.SpanContext.SpanID()]
+		if len(kids) == 0 {
+			return span.Name
+		}
+		names := make([]string, len(kids))
+		for i, kid := range kids {
+			names[i] = render(kid)
+		}
+		return span.Name + "(" + strings.Join(names, " ") + ")"
+	}
+	return render(root)
+}
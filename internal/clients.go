@@ -2,75 +2,324 @@ package clients
 
 import (
 	"context"
+	"flag"
+	"time"
+	"toy/internal/breaker"
+	"toy/internal/discovery"
+	"toy/internal/grpcerr"
 	"toy/schema/authenticatorgrpc"
+	"toy/schema/calculatorgrpc"
 	"toy/schema/usergrpc"
 
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/status"
 )
 
-type AuthenticatorClient struct{ addr string }
+// Config holds the connection settings shared by the clients.
+type Config struct {
+	// KeepaliveTime is the interval of keepalive pings on an idle
+	// connection, disabled when 0.
+	KeepaliveTime    time.Duration
+	KeepaliveTimeout time.Duration
 
-func NewAuthenticatorClient(addr string) AuthenticatorClient {
-	return AuthenticatorClient{addr}
+	BackoffBaseDelay  time.Duration
+	BackoffMaxDelay   time.Duration
+	MinConnectTimeout time.Duration
+
+	// ResolverRefresh is how often file:/// targets are re-read.
+	ResolverRefresh time.Duration
+
+	CallPolicy     CallPolicy
+	MethodTimeouts MethodTimeouts
+	HedgedMethods  HedgedMethods
+
+	// Breaker guards the user client, disabled when its FailureThreshold
+	// is 0.
+	Breaker breaker.Config
+
+	// DialOptions are appended to the options the clients dial with.
+	DialOptions []grpc.DialOption
+}
+
+func DefaultConfig() Config {
+	return Config{
+		KeepaliveTime:     30 * time.Second,
+		KeepaliveTimeout:  10 * time.Second,
+		BackoffBaseDelay:  backoff.DefaultConfig.BaseDelay,
+		BackoffMaxDelay:   backoff.DefaultConfig.MaxDelay,
+		MinConnectTimeout: 20 * time.Second,
+		ResolverRefresh:   discovery.DefaultRefreshInterval,
+		CallPolicy:        DefaultCallPolicy(),
+		MethodTimeouts: MethodTimeouts{
+			"/toy.Authenticator/AuthenticatePassword": 5 * time.Second,
+			"/toy.UserService/VerifyPassword":         2 * time.Second,
+		},
+		HedgedMethods: HedgedMethods{
+			"/toy.Authenticator/ValidateToken": true,
+			"/toy.UserService/GetUserByName":   true,
+			"/toy.UserService/VerifyPassword":  true,
+		},
+		Breaker: breaker.DefaultConfig(),
+	}
 }
 
-func (a AuthenticatorClient) newClient() (*grpc.ClientConn, authenticatorgrpc.AuthenticatorClient, error) {
-	conn, err := grpc.Dial(a.addr,
+// RegisterFlags binds the keepalive, backoff, resolver, call policy and
+// circuit breaker flags to fs and returns the Config they populate, starting
+// from DefaultConfig.
+func RegisterFlags(fs *flag.FlagSet) *Config {
+	cfg := DefaultConfig()
+	fs.DurationVar(&cfg.KeepaliveTime, "keepaliveTime", cfg.KeepaliveTime, "interval of client keepalive pings, disabled when 0")
+	fs.DurationVar(&cfg.KeepaliveTimeout, "keepaliveTimeout", cfg.KeepaliveTimeout, "time to wait for a keepalive ack before closing the connection")
+	fs.DurationVar(&cfg.BackoffBaseDelay, "backoffBaseDelay", cfg.BackoffBaseDelay, "delay before the first reconnect attempt")
+	fs.DurationVar(&cfg.BackoffMaxDelay, "backoffMaxDelay", cfg.BackoffMaxDelay, "upper bound of the reconnect backoff")
+	fs.DurationVar(&cfg.ResolverRefresh, "resolverRefresh", cfg.ResolverRefresh, "how often file:/// backend lists are re-read")
+	fs.DurationVar(&cfg.CallPolicy.Timeout, "callTimeout", cfg.CallPolicy.Timeout, "deadline of calls to methods without a -methodTimeout")
+	fs.Var(cfg.MethodTimeouts, "methodTimeout", "per method deadlines as /package.Service/Method=duration pairs")
+	fs.IntVar(&cfg.CallPolicy.MaxAttempts, "maxAttempts", cfg.CallPolicy.MaxAttempts, "attempts per call, retrying on Unavailable")
+	fs.DurationVar(&cfg.CallPolicy.InitialBackoff, "retryBackoff", cfg.CallPolicy.InitialBackoff, "delay before the first retry, doubled on every retry")
+	fs.DurationVar(&cfg.CallPolicy.HedgingDelay, "hedgingDelay", cfg.CallPolicy.HedgingDelay, "start another attempt when a call takes longer, disabled when 0")
+	fs.Var(cfg.HedgedMethods, "hedgedMethod", "idempotent methods that -hedgingDelay applies to, as comma separated /package.Service/Method names")
+	fs.IntVar(&cfg.Breaker.FailureThreshold, "breakerFailures", cfg.Breaker.FailureThreshold, "consecutive user service failures that open the circuit breaker, disabled when 0")
+	fs.DurationVar(&cfg.Breaker.OpenTimeout, "breakerOpenTimeout", cfg.Breaker.OpenTimeout, "how long the circuit breaker stays open before a trial call")
+	fs.IntVar(&cfg.Breaker.HalfOpenRequests, "breakerHalfOpenRequests", cfg.Breaker.HalfOpenRequests, "concurrent trial calls while the circuit breaker is half-open")
+	return &cfg
+}
+
+func (c Config) dialOptions() []grpc.DialOption {
+	bc := backoff.DefaultConfig
+	bc.BaseDelay = c.BackoffBaseDelay
+	bc.MaxDelay = c.BackoffMaxDelay
+
+	opts := []grpc.DialOption{
 		grpc.WithInsecure(),
-		grpc.WithUnaryInterceptor(otelgrpc.UnaryClientInterceptor()),
+		grpc.WithChainUnaryInterceptor(c.unaryCallPolicy(), otelgrpc.UnaryClientInterceptor()),
 		grpc.WithStreamInterceptor(otelgrpc.StreamClientInterceptor()),
-	)
-	if err != nil {
-		return nil, nil, err
+		grpc.WithConnectParams(grpc.ConnectParams{Backoff: bc, MinConnectTimeout: c.MinConnectTimeout}),
+		grpc.WithResolvers(discovery.NewStaticBuilder(), discovery.NewFileBuilder(c.ResolverRefresh)),
+		grpc.WithDefaultServiceConfig(`{"loadBalancingConfig": [{"round_robin": {}}]}`),
 	}
+	opts = append(opts, c.DialOptions...)
 
-	client := authenticatorgrpc.NewAuthenticatorClient(conn)
+	if c.KeepaliveTime > 0 {
+		opts = append(opts, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                c.KeepaliveTime,
+			Timeout:             c.KeepaliveTimeout,
+			PermitWithoutStream: true,
+		}))
+	}
 
-	return conn, client, nil
+	return opts
 }
 
-func (a AuthenticatorClient) AuthenticatePassword(ctx context.Context, username, password string) (*authenticatorgrpc.AuthenticatePasswordResponse, error) {
-	conn, client, err := a.newClient()
+// dial connects to target, which is either a single address, a comma
+// separated list of addresses, or a dns:///, static:/// or file:/// target.
+// Calls are balanced round robin over the resolved addresses. The connection
+// is established in the background and shared by all calls of a client, its
+// Close releases it.
+func dial(target string, cfg Config) (*grpc.ClientConn, error) {
+	return grpc.Dial(discovery.Target(target), cfg.dialOptions()...)
+}
+
+// ServerKeepalive returns the server options that allow the keepalive pings
+// sent by clients using DefaultConfig.
+func ServerKeepalive() grpc.ServerOption {
+	return grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+		MinTime:             10 * time.Second,
+		PermitWithoutStream: true,
+	})
+}
+
+// AuthenticatorService is the authenticator API used by the services,
+// implemented by AuthenticatorClient and by the fakes in clientstest.
+type AuthenticatorService interface {
+	AuthenticatePassword(ctx context.Context, username, password string) (*authenticatorgrpc.AuthenticatePasswordResponse, error)
+	ValidateToken(ctx context.Context, token string) (*authenticatorgrpc.ValidateTokenResponse, error)
+}
+
+// UserService is the user API used by the services, implemented by
+// UserClient and by the fakes in clientstest.
+type UserService interface {
+	GetUserByName(ctx context.Context, name string) (*usergrpc.GetUserByNameResponse, error)
+	VerifyPassword(ctx context.Context, username, password string) (*usergrpc.VerifyPasswordResponse, error)
+}
+
+// CalculatorService is the calculator API used by the services, implemented
+// by CalculatorClient and by the fakes in clientstest.
+type CalculatorService interface {
+	Add(ctx context.Context, x, y int32) (*calculatorgrpc.AddValue, error)
+	Substract(ctx context.Context, x, y int32) (*calculatorgrpc.SubstractValue, error)
+	Multiply(ctx context.Context, x, y int32) (*calculatorgrpc.MultiplyValue, error)
+	Divide(ctx context.Context, x, y int32) (*calculatorgrpc.DivideValue, error)
+	Evaluate(ctx context.Context) (calculatorgrpc.Calculator_EvaluateClient, error)
+	EvaluateExpression(ctx context.Context, expr string) (*calculatorgrpc.ExpressionValue, error)
+}
+
+var (
+	_ AuthenticatorService = AuthenticatorClient{}
+	_ UserService          = UserClient{}
+	_ CalculatorService    = CalculatorClient{}
+)
+
+type AuthenticatorClient struct {
+	conn   *grpc.ClientConn
+	client authenticatorgrpc.AuthenticatorClient
+}
+
+func NewAuthenticatorClient(target string, cfg Config) (AuthenticatorClient, error) {
+	conn, err := dial(target, cfg)
 	if err != nil {
-		return nil, err
+		return AuthenticatorClient{}, err
 	}
 
-	defer conn.Close()
+	return AuthenticatorClient{conn: conn, client: authenticatorgrpc.NewAuthenticatorClient(conn)}, nil
+}
+
+func (a AuthenticatorClient) AuthenticatePassword(ctx context.Context, username, password string) (*authenticatorgrpc.AuthenticatePasswordResponse, error) {
+	return a.client.AuthenticatePassword(ctx, &authenticatorgrpc.AuthenticatePasswordReq{Username: username, Password: password})
+}
 
-	return client.AuthenticatePassword(ctx, &authenticatorgrpc.AuthenticatePasswordReq{Username: username, Password: password})
+func (a AuthenticatorClient) ValidateToken(ctx context.Context, token string) (*authenticatorgrpc.ValidateTokenResponse, error) {
+	return a.client.ValidateToken(ctx, &authenticatorgrpc.ValidateTokenReq{Token: token})
 }
 
-type UserClient struct {
-	addr string
+func (a AuthenticatorClient) Close() error {
+	return a.conn.Close()
 }
 
-func NewUserClient(addr string) UserClient {
-	return UserClient{addr: addr}
+type UserClient struct {
+	conn    *grpc.ClientConn
+	client  usergrpc.UserServiceClient
+	breaker *breaker.Breaker
 }
 
-func (u UserClient) newClient(addr string) (*grpc.ClientConn, usergrpc.UserServiceClient, error) {
-	conn, err := grpc.Dial(addr,
-		grpc.WithInsecure(),
-		grpc.WithUnaryInterceptor(otelgrpc.UnaryClientInterceptor()),
-		grpc.WithStreamInterceptor(otelgrpc.StreamClientInterceptor()),
-	)
+func NewUserClient(target string, cfg Config) (UserClient, error) {
+	conn, err := dial(target, cfg)
 	if err != nil {
-		return nil, nil, err
+		return UserClient{}, err
 	}
 
-	client := usergrpc.NewUserServiceClient(conn)
+	u := UserClient{conn: conn, client: usergrpc.NewUserServiceClient(conn)}
+	if cfg.Breaker.FailureThreshold > 0 {
+		u.breaker = breaker.New("user", cfg.Breaker)
+	}
 
-	return conn, client, nil
+	return u, nil
 }
 
 func (u UserClient) GetUserByName(ctx context.Context, name string) (*usergrpc.GetUserByNameResponse, error) {
-	conn, client, err := u.newClient(u.addr)
+	var resp *usergrpc.GetUserByNameResponse
+	err := u.guard(ctx, func() (err error) {
+		resp, err = u.client.GetUserByName(ctx, &usergrpc.GetUserByNameReq{Name: name})
+		return err
+	})
+	return resp, err
+}
+
+func (u UserClient) VerifyPassword(ctx context.Context, username, password string) (*usergrpc.VerifyPasswordResponse, error) {
+	var resp *usergrpc.VerifyPasswordResponse
+	err := u.guard(ctx, func() (err error) {
+		resp, err = u.client.VerifyPassword(ctx, &usergrpc.VerifyPasswordReq{Username: username, Password: password})
+		return err
+	})
+	return resp, err
+}
+
+// guard runs call through the circuit breaker. It fails fast with
+// codes.Unavailable and the CIRCUIT_OPEN reason while the breaker is open,
+// which callers do not retry, and annotates the caller's span with the
+// breaker state.
+func (u UserClient) guard(ctx context.Context, call func() error) error {
+	if u.breaker == nil {
+		return call()
+	}
+
+	span := trace.SpanFromContext(ctx)
+	generation, err := u.breaker.Allow()
+	if err != nil {
+		span.SetAttributes(breakerStateKey.String(u.breaker.State().String()))
+		return grpcerr.New(codes.Unavailable, err.Error(), circuitOpenReason, errorDomain)
+	}
+
+	err = call()
+	u.breaker.Done(generation, !breakerFailure(err))
+	span.SetAttributes(breakerStateKey.String(u.breaker.State().String()))
+
+	return err
+}
+
+// Breaker returns the circuit breaker of the client, nil when disabled.
+func (u UserClient) Breaker() *breaker.Breaker {
+	return u.breaker
+}
+
+func (u UserClient) Close() error {
+	return u.conn.Close()
+}
+
+type CalculatorClient struct {
+	conn   *grpc.ClientConn
+	client calculatorgrpc.CalculatorClient
+}
+
+func NewCalculatorClient(target string, cfg Config) (CalculatorClient, error) {
+	conn, err := dial(target, cfg)
 	if err != nil {
-		return nil, err
+		return CalculatorClient{}, err
 	}
 
-	defer conn.Close()
+	return CalculatorClient{conn: conn, client: calculatorgrpc.NewCalculatorClient(conn)}, nil
+}
+
+func (c CalculatorClient) Add(ctx context.Context, x, y int32) (*calculatorgrpc.AddValue, error) {
+	return c.client.Add(ctx, &calculatorgrpc.Values{X: x, Y: y})
+}
+
+func (c CalculatorClient) Substract(ctx context.Context, x, y int32) (*calculatorgrpc.SubstractValue, error) {
+	return c.client.Substract(ctx, &calculatorgrpc.Values{X: x, Y: y})
+}
+
+func (c CalculatorClient) Multiply(ctx context.Context, x, y int32) (*calculatorgrpc.MultiplyValue, error) {
+	return c.client.Multiply(ctx, &calculatorgrpc.Values{X: x, Y: y})
+}
+
+func (c CalculatorClient) Divide(ctx context.Context, x, y int32) (*calculatorgrpc.DivideValue, error) {
+	return c.client.Divide(ctx, &calculatorgrpc.Values{X: x, Y: y})
+}
+
+// Evaluate opens an Evaluate stream. The call policy does not apply to
+// streams, ctx bounds the whole stream.
+func (c CalculatorClient) Evaluate(ctx context.Context) (calculatorgrpc.Calculator_EvaluateClient, error) {
+	return c.client.Evaluate(ctx)
+}
+
+func (c CalculatorClient) EvaluateExpression(ctx context.Context, expr string) (*calculatorgrpc.ExpressionValue, error) {
+	return c.client.EvaluateExpression(ctx, &calculatorgrpc.Expression{Expression: expr})
+}
+
+func (c CalculatorClient) Close() error {
+	return c.conn.Close()
+}
 
-	return client.GetUserByName(ctx, &usergrpc.GetUserByNameReq{Name: name})
+const breakerStateKey = attribute.Key("circuit_breaker.state")
+
+const (
+	errorDomain       = "clients.toy"
+	circuitOpenReason = "CIRCUIT_OPEN"
+)
+
+// breakerFailure reports whether err means the service is unhealthy, as
+// opposed to an error about the request itself such as NotFound.
+func breakerFailure(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Internal, codes.Unknown:
+		return true
+	default:
+		return false
+	}
 }
@@ -0,0 +1,210 @@
+package clients_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+	clients "toy/internal"
+	"toy/internal/clientstest"
+	"toy/internal/grpcerr"
+	"toy/schema/usergrpc"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type userServer struct {
+	usergrpc.UnimplementedUserServiceServer
+
+	// delay is how long every call takes, and calls counts them. err, when
+	// set, fails every call.
+	delay time.Duration
+	calls int32
+	err   error
+}
+
+func (s *userServer) GetUserByName(ctx context.Context, req *usergrpc.GetUserByNameReq) (*usergrpc.GetUserByNameResponse, error) {
+	atomic.AddInt32(&s.calls, 1)
+	time.Sleep(s.delay)
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &usergrpc.GetUserByNameResponse{User: &usergrpc.User{Id: "1", Username: req.Name}}, nil
+}
+
+func serveUsers(tb testing.TB, srv *userServer) clients.Config {
+	return clientstest.Serve(tb, func(s *grpc.Server) {
+		usergrpc.RegisterUserServiceServer(s, srv)
+	})
+}
+
+func TestHedgedMethods(t *testing.T) {
+	tests := []struct {
+		name   string
+		hedged clients.HedgedMethods
+		want   int32
+	}{
+		{name: "hedged", hedged: clients.HedgedMethods{"/toy.UserService/GetUserByName": true}, want: 3},
+		{name: "not hedged", hedged: clients.HedgedMethods{}, want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := &userServer{delay: 100 * time.Millisecond}
+			cfg := serveUsers(t, srv)
+			cfg.CallPolicy.HedgingDelay = 10 * time.Millisecond
+			cfg.HedgedMethods = tt.hedged
+
+			client, err := clients.NewUserClient(clientstest.BufTarget, cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer client.Close()
+
+			if _, err := client.GetUserByName(context.Background(), "kasutaja"); err != nil {
+				t.Fatal(err)
+			}
+			if got := atomic.LoadInt32(&srv.calls); got != tt.want {
+				t.Errorf("got %d attempts, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHedgingBacksOffWhenAllAttemptsFail(t *testing.T) {
+	srv := &userServer{err: status.Error(codes.Unavailable, "unavailable")}
+	cfg := serveUsers(t, srv)
+	cfg.CallPolicy.MaxAttempts = 3
+	cfg.CallPolicy.HedgingDelay = time.Hour
+	cfg.CallPolicy.InitialBackoff = 50 * time.Millisecond
+	cfg.HedgedMethods = clients.HedgedMethods{"/toy.UserService/GetUserByName": true}
+	cfg.Breaker.FailureThreshold = 0
+
+	client, err := clients.NewUserClient(clientstest.BufTarget, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	start := time.Now()
+	if _, err := client.GetUserByName(context.Background(), "kasutaja"); status.Code(err) != codes.Unavailable {
+		t.Fatalf("got %v, want Unavailable", err)
+	}
+
+	// The backoffs are 50ms and 100ms with +-20% jitter.
+	if elapsed := time.Since(start); elapsed < 120*time.Millisecond {
+		t.Errorf("3 attempts took %s, want them to back off", elapsed)
+	}
+	if got := atomic.LoadInt32(&srv.calls); got != 3 {
+		t.Errorf("got %d attempts, want 3", got)
+	}
+}
+
+func TestOpenBreakerFailsFast(t *testing.T) {
+	srv := &userServer{err: status.Error(codes.Unavailable, "unavailable")}
+	cfg := serveUsers(t, srv)
+	cfg.CallPolicy.MaxAttempts = 3
+	cfg.CallPolicy.InitialBackoff = 10 * time.Millisecond
+	cfg.Breaker.FailureThreshold = 1
+	cfg.Breaker.OpenTimeout = time.Hour
+
+	client, err := clients.NewUserClient(clientstest.BufTarget, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	if _, err := client.GetUserByName(context.Background(), "kasutaja"); status.Code(err) != codes.Unavailable {
+		t.Fatalf("got %v, want Unavailable", err)
+	}
+	calls := atomic.LoadInt32(&srv.calls)
+
+	start := time.Now()
+	_, err = client.GetUserByName(context.Background(), "kasutaja")
+	if status.Code(err) != codes.Unavailable || grpcerr.Reason(err) != "CIRCUIT_OPEN" {
+		t.Fatalf("got %v, want Unavailable with CIRCUIT_OPEN", err)
+	}
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Errorf("open breaker took %s to reject", elapsed)
+	}
+	if got := atomic.LoadInt32(&srv.calls) - calls; got != 0 {
+		t.Errorf("open breaker let %d attempts through", got)
+	}
+}
+
+// TestCircuitOpenNotRetried checks that the rejections of an open breaker in
+// the called service are not retried or hedged.
+func TestCircuitOpenNotRetried(t *testing.T) {
+	tests := []struct {
+		name   string
+		hedged clients.HedgedMethods
+	}{
+		{name: "retried", hedged: clients.HedgedMethods{}},
+		{name: "hedged", hedged: clients.HedgedMethods{"/toy.UserService/GetUserByName": true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := &userServer{err: grpcerr.New(codes.Unavailable, "circuit breaker is open", "CIRCUIT_OPEN", "clients.toy")}
+			cfg := serveUsers(t, srv)
+			cfg.CallPolicy.MaxAttempts = 3
+			cfg.CallPolicy.HedgingDelay = 10 * time.Millisecond
+			cfg.CallPolicy.InitialBackoff = 10 * time.Millisecond
+			cfg.HedgedMethods = tt.hedged
+			cfg.Breaker.FailureThreshold = 0
+
+			client, err := clients.NewUserClient(clientstest.BufTarget, cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer client.Close()
+
+			if _, err := client.GetUserByName(context.Background(), "kasutaja"); grpcerr.Reason(err) != "CIRCUIT_OPEN" {
+				t.Fatalf("got %v, want CIRCUIT_OPEN", err)
+			}
+			if got := atomic.LoadInt32(&srv.calls); got != 1 {
+				t.Errorf("got %d attempts, want 1", got)
+			}
+		})
+	}
+}
+
+// BenchmarkSharedConn calls through one UserClient, as the services do.
+func BenchmarkSharedConn(b *testing.B) {
+	client, err := clients.NewUserClient(clientstest.BufTarget, serveUsers(b, &userServer{}))
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := client.GetUserByName(ctx, "kasutaja"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkDialPerCall dials a new connection for every call, which is what
+// the clients did before the connection was shared.
+func BenchmarkDialPerCall(b *testing.B) {
+	cfg := serveUsers(b, &userServer{})
+
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		client, err := clients.NewUserClient(clientstest.BufTarget, cfg)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, err := client.GetUserByName(ctx, "kasutaja"); err != nil {
+			b.Fatal(err)
+		}
+		client.Close()
+	}
+}
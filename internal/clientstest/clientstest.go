@@ -0,0 +1,376 @@
+package clientstest
+
+import (
+	"context"
+	"io"
+	"math"
+	"net"
+	"sync"
+	"testing"
+	clients "toy/internal"
+	"toy/schema/authenticatorgrpc"
+	"toy/schema/calculatorgrpc"
+	"toy/schema/usergrpc"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+)
+
+// UserService is an in-memory clients.UserService. Passwords are kept in
+// plain text.
+type UserService struct {
+	mu        sync.RWMutex
+	users     map[string]*usergrpc.User
+	passwords map[string]string
+
+	// Err, when set, is returned by every call.
+	Err error
+}
+
+var _ clients.UserService = (*UserService)(nil)
+
+func NewUserService() *UserService {
+	return &UserService{
+		users:     make(map[string]*usergrpc.User),
+		passwords: make(map[string]string),
+	}
+}
+
+func (us *UserService) Add(u *usergrpc.User, password string) {
+	us.mu.Lock()
+	defer us.mu.Unlock()
+	us.users[u.Username] = proto.Clone(u).(*usergrpc.User)
+	us.passwords[u.Username] = password
+}
+
+func (us *UserService) GetUserByName(ctx context.Context, name string) (*usergrpc.GetUserByNameResponse, error) {
+	if us.Err != nil {
+		return nil, us.Err
+	}
+
+	us.mu.RLock()
+	defer us.mu.RUnlock()
+	u, ok := us.users[name]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
+
+	return &usergrpc.GetUserByNameResponse{User: proto.Clone(u).(*usergrpc.User)}, nil
+}
+
+func (us *UserService) VerifyPassword(ctx context.Context, username, password string) (*usergrpc.VerifyPasswordResponse, error) {
+	if us.Err != nil {
+		return nil, us.Err
+	}
+
+	us.mu.RLock()
+	defer us.mu.RUnlock()
+	u, ok := us.users[username]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
+	if password != us.passwords[username] {
+		return &usergrpc.VerifyPasswordResponse{}, nil
+	}
+
+	return &usergrpc.VerifyPasswordResponse{Match: true, User: proto.Clone(u).(*usergrpc.User)}, nil
+}
+
+// AuthenticatorService is an in-memory clients.AuthenticatorService that
+// returns Token for the username and password pairs in Passwords, and
+// accepts the tokens in Claims.
+type AuthenticatorService struct {
+	Passwords map[string]string
+	Token     string
+	Claims    map[string]*authenticatorgrpc.UserClaims
+
+	// Err, when set, is returned by every call.
+	Err error
+}
+
+var _ clients.AuthenticatorService = (*AuthenticatorService)(nil)
+
+func (as *AuthenticatorService) AuthenticatePassword(ctx context.Context, username, password string) (*authenticatorgrpc.AuthenticatePasswordResponse, error) {
+	if as.Err != nil {
+		return nil, as.Err
+	}
+
+	if want, ok := as.Passwords[username]; !ok || password != want {
+		return nil, status.Error(codes.Unauthenticated, "invalid username or password")
+	}
+
+	return &authenticatorgrpc.AuthenticatePasswordResponse{Token: as.Token}, nil
+}
+
+func (as *AuthenticatorService) ValidateToken(ctx context.Context, token string) (*authenticatorgrpc.ValidateTokenResponse, error) {
+	if as.Err != nil {
+		return nil, as.Err
+	}
+
+	claims, ok := as.Claims[token]
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "invalid token")
+	}
+
+	return &authenticatorgrpc.ValidateTokenResponse{Claims: proto.Clone(claims).(*authenticatorgrpc.UserClaims)}, nil
+}
+
+// CalculatorService is a clients.CalculatorService computing the results
+// locally, reporting int32 overflows and division by zero as
+// InvalidArgument. Expressions are not parsed, EvaluateExpression returns
+// their value from Expressions.
+type CalculatorService struct {
+	Expressions map[string]int32
+
+	// Err, when set, is returned by every call.
+	Err error
+}
+
+var _ clients.CalculatorService = (*CalculatorService)(nil)
+
+func (cs *CalculatorService) Add(ctx context.Context, x, y int32) (*calculatorgrpc.AddValue, error) {
+	v, err := cs.calculate(calculatorgrpc.Operation_OP_ADD, x, y)
+	if err != nil {
+		return nil, err
+	}
+	return &calculatorgrpc.AddValue{Value: v}, nil
+}
+
+func (cs *CalculatorService) Substract(ctx context.Context, x, y int32) (*calculatorgrpc.SubstractValue, error) {
+	v, err := cs.calculate(calculatorgrpc.Operation_OP_SUBSTRACT, x, y)
+	if err != nil {
+		return nil, err
+	}
+	return &calculatorgrpc.SubstractValue{Value: v}, nil
+}
+
+func (cs *CalculatorService) Multiply(ctx context.Context, x, y int32) (*calculatorgrpc.MultiplyValue, error) {
+	v, err := cs.calculate(calculatorgrpc.Operation_OP_MULTIPLY, x, y)
+	if err != nil {
+		return nil, err
+	}
+	return &calculatorgrpc.MultiplyValue{Value: v}, nil
+}
+
+func (cs *CalculatorService) Divide(ctx context.Context, x, y int32) (*calculatorgrpc.DivideValue, error) {
+	v, err := cs.calculate(calculatorgrpc.Operation_OP_DIVIDE, x, y)
+	if err != nil {
+		return nil, err
+	}
+	return &calculatorgrpc.DivideValue{Value: v}, nil
+}
+
+// Evaluate returns a stream that computes the result of every operation as
+// it is sent. Only Send, CloseSend, Recv and Context are implemented.
+func (cs *CalculatorService) Evaluate(ctx context.Context) (calculatorgrpc.Calculator_EvaluateClient, error) {
+	if cs.Err != nil {
+		return nil, cs.Err
+	}
+	return &evaluateStream{ctx: ctx, cs: cs, ready: make(chan struct{}, 1)}, nil
+}
+
+func (cs *CalculatorService) EvaluateExpression(ctx context.Context, expr string) (*calculatorgrpc.ExpressionValue, error) {
+	if cs.Err != nil {
+		return nil, cs.Err
+	}
+
+	v, ok := cs.Expressions[expr]
+	if !ok {
+		return nil, status.Error(codes.InvalidArgument, "invalid expression")
+	}
+	return &calculatorgrpc.ExpressionValue{Value: v}, nil
+}
+
+func (cs *CalculatorService) calculate(op calculatorgrpc.Operation_Op, x, y int32) (int32, error) {
+	if cs.Err != nil {
+		return 0, cs.Err
+	}
+
+	var v int64
+	switch op {
+	case calculatorgrpc.Operation_OP_ADD:
+		v = int64(x) + int64(y)
+	case calculatorgrpc.Operation_OP_SUBSTRACT:
+		v = int64(x) - int64(y)
+	case calculatorgrpc.Operation_OP_MULTIPLY:
+		v = int64(x) * int64(y)
+	case calculatorgrpc.Operation_OP_DIVIDE:
+		if y == 0 {
+			return 0, status.Error(codes.InvalidArgument, "division by zero")
+		}
+		v = int64(x) / int64(y)
+	default:
+		return 0, status.Error(codes.InvalidArgument, "unknown operation")
+	}
+
+	if v > math.MaxInt32 || v < math.MinInt32 {
+		return 0, status.Error(codes.InvalidArgument, "result overflows int32")
+	}
+	return int32(v), nil
+}
+
+type evaluateStream struct {
+	// ClientStream is nil, calling its other methods panics.
+	grpc.ClientStream
+
+	ctx   context.Context
+	cs    *CalculatorService
+	ready chan struct{}
+
+	mu      sync.Mutex
+	result  int32
+	pending []*calculatorgrpc.EvaluateValue
+	err     error
+	closed  bool
+}
+
+func (s *evaluateStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *evaluateStream) Send(op *calculatorgrpc.Operation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil || s.closed {
+		return io.EOF
+	}
+
+	v, err := s.cs.calculate(op.Op, s.result, op.Value)
+	if err != nil {
+		s.err = err
+	} else {
+		s.result = v
+		s.pending = append(s.pending, &calculatorgrpc.EvaluateValue{Value: v})
+	}
+	s.notify()
+	return nil
+}
+
+func (s *evaluateStream) CloseSend() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	s.notify()
+	return nil
+}
+
+func (s *evaluateStream) Recv() (*calculatorgrpc.EvaluateValue, error) {
+	for {
+		s.mu.Lock()
+		switch {
+		case len(s.pending) > 0:
+			v := s.pending[0]
+			s.pending = s.pending[1:]
+			s.mu.Unlock()
+			return v, nil
+		case s.err != nil:
+			s.mu.Unlock()
+			return nil, s.err
+		case s.closed:
+			s.mu.Unlock()
+			return nil, io.EOF
+		}
+		s.mu.Unlock()
+
+		select {
+		case <-s.ctx.Done():
+			return nil, status.FromContextError(s.ctx.Err()).Err()
+		case <-s.ready:
+		}
+	}
+}
+
+// notify wakes up a blocked Recv. It must be called with s.mu held.
+func (s *evaluateStream) notify() {
+	select {
+	case s.ready <- struct{}{}:
+	default:
+	}
+}
+
+const (
+	bufSize = 1 << 20
+	// BufTarget is the target the clients returned by the Start helpers dial.
+	BufTarget = "bufnet"
+)
+
+// Serve starts a gRPC server on an in-memory bufconn listener, registers the
+// services on it with register, and returns a client Config dialing that
+// listener. The server is stopped when the test finishes.
+func Serve(tb testing.TB, register func(*grpc.Server)) clients.Config {
+	tb.Helper()
+
+	lis := bufconn.Listen(bufSize)
+	srv := grpc.NewServer(
+		grpc.UnaryInterceptor(otelgrpc.UnaryServerInterceptor()),
+		grpc.StreamInterceptor(otelgrpc.StreamServerInterceptor()),
+	)
+	register(srv)
+
+	go srv.Serve(lis)
+	tb.Cleanup(srv.Stop)
+
+	cfg := clients.DefaultConfig()
+	cfg.DialOptions = append(cfg.DialOptions, grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return lis.DialContext(ctx)
+	}))
+	return cfg
+}
+
+// StartUserServer serves srv over bufconn and returns a UserClient connected
+// to it.
+func StartUserServer(tb testing.TB, srv usergrpc.UserServiceServer) clients.UserClient {
+	tb.Helper()
+
+	cfg := Serve(tb, func(s *grpc.Server) {
+		usergrpc.RegisterUserServiceServer(s, srv)
+	})
+
+	client, err := clients.NewUserClient(BufTarget, cfg)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() { client.Close() })
+
+	return client
+}
+
+// StartAuthenticatorServer serves srv over bufconn and returns an
+// AuthenticatorClient connected to it.
+func StartAuthenticatorServer(tb testing.TB, srv authenticatorgrpc.AuthenticatorServer) clients.AuthenticatorClient {
+	tb.Helper()
+
+	cfg := Serve(tb, func(s *grpc.Server) {
+		authenticatorgrpc.RegisterAuthenticatorServer(s, srv)
+	})
+
+	client, err := clients.NewAuthenticatorClient(BufTarget, cfg)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() { client.Close() })
+
+	return client
+}
+
+// StartCalculatorServer serves srv over bufconn and returns a
+// CalculatorClient connected to it.
+func StartCalculatorServer(tb testing.TB, srv calculatorgrpc.CalculatorServer) clients.CalculatorClient {
+	tb.Helper()
+
+	cfg := Serve(tb, func(s *grpc.Server) {
+		calculatorgrpc.RegisterCalculatorServer(s, srv)
+	})
+
+	client, err := clients.NewCalculatorClient(BufTarget, cfg)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() { client.Close() })
+
+	return client
+}
@@ -0,0 +1,177 @@
+package discovery
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/resolver"
+)
+
+const (
+	// StaticScheme resolves "static:///host1:port,host2:port" to the listed
+	// addresses.
+	StaticScheme = "static"
+	// FileScheme resolves "file:///path/to/backends" to the addresses listed
+	// in the file, one per line, and picks up changes to it.
+	FileScheme = "file"
+
+	DefaultRefreshInterval = 5 * time.Second
+)
+
+// Target turns a comma separated list of addresses into a static:/// target.
+// Single addresses and targets that already have a scheme are returned
+// unchanged.
+func Target(addr string) string {
+	if strings.Contains(addr, ",") && !strings.Contains(addr, "://") {
+		return StaticScheme + ":///" + addr
+	}
+	return addr
+}
+
+type staticBuilder struct{}
+
+func NewStaticBuilder() resolver.Builder {
+	return staticBuilder{}
+}
+
+func (staticBuilder) Scheme() string { return StaticScheme }
+
+func (staticBuilder) Build(target resolver.Target, cc resolver.ClientConn, _ resolver.BuildOptions) (resolver.Resolver, error) {
+	addrs := parseAddrs(strings.Split(strings.TrimPrefix(target.URL.Path, "/"), ","))
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("no addresses in target %q", target.URL.String())
+	}
+
+	if err := cc.UpdateState(resolver.State{Addresses: addrs}); err != nil {
+		return nil, err
+	}
+	return nopResolver{}, nil
+}
+
+type nopResolver struct{}
+
+func (nopResolver) ResolveNow(resolver.ResolveNowOptions) {}
+
+func (nopResolver) Close() {}
+
+type fileBuilder struct {
+	refresh time.Duration
+}
+
+// NewFileBuilder returns a builder for file:/// targets that re-reads the
+// file every refresh interval.
+func NewFileBuilder(refresh time.Duration) resolver.Builder {
+	if refresh <= 0 {
+		refresh = DefaultRefreshInterval
+	}
+	return fileBuilder{refresh: refresh}
+}
+
+func (fileBuilder) Scheme() string { return FileScheme }
+
+func (b fileBuilder) Build(target resolver.Target, cc resolver.ClientConn, _ resolver.BuildOptions) (resolver.Resolver, error) {
+	r := &fileResolver{
+		path:    target.URL.Path,
+		cc:      cc,
+		resolve: make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+
+	if err := r.update(); err != nil {
+		return nil, err
+	}
+
+	r.wg.Add(1)
+	go r.watch(b.refresh)
+
+	return r, nil
+}
+
+type fileResolver struct {
+	path string
+	cc   resolver.ClientConn
+	last []byte
+
+	resolve chan struct{}
+	done    chan struct{}
+	wg      sync.WaitGroup
+}
+
+func (r *fileResolver) watch(refresh time.Duration) {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(refresh)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.done:
+			return
+		case <-ticker.C:
+		case <-r.resolve:
+		}
+
+		if err := r.update(); err != nil {
+			r.cc.ReportError(err)
+		}
+	}
+}
+
+// update reads the file and sends its addresses to the ClientConn when they
+// changed since the last read.
+func (r *fileResolver) update() error {
+	b, err := ioutil.ReadFile(r.path)
+	if err != nil {
+		return err
+	}
+	if r.last != nil && bytes.Equal(b, r.last) {
+		return nil
+	}
+
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+
+	addrs := parseAddrs(lines)
+	if len(addrs) == 0 {
+		return fmt.Errorf("no addresses in %s", r.path)
+	}
+
+	if err := r.cc.UpdateState(resolver.State{Addresses: addrs}); err != nil {
+		return err
+	}
+	r.last = b
+	return nil
+}
+
+func (r *fileResolver) ResolveNow(resolver.ResolveNowOptions) {
+	select {
+	case r.resolve <- struct{}{}:
+	default:
+	}
+}
+
+func (r *fileResolver) Close() {
+	close(r.done)
+	r.wg.Wait()
+}
+
+// parseAddrs trims the addresses and drops empty ones and # comments.
+func parseAddrs(lines []string) []resolver.Address {
+	var addrs []resolver.Address
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		addrs = append(addrs, resolver.Address{Addr: line})
+	}
+	return addrs
+}
@@ -0,0 +1,176 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+	"toy/schema/usergrpc"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/resolver"
+)
+
+func TestTarget(t *testing.T) {
+	tests := []struct {
+		addr string
+		want string
+	}{
+		{addr: ":8081", want: ":8081"},
+		{addr: "a:8081,b:8081", want: "static:///a:8081,b:8081"},
+		{addr: "dns:///users:8081", want: "dns:///users:8081"},
+		{addr: "static:///a:8081,b:8081", want: "static:///a:8081,b:8081"},
+		{addr: "file:///etc/backends", want: "file:///etc/backends"},
+	}
+
+	for _, tt := range tests {
+		if got := Target(tt.addr); got != tt.want {
+			t.Errorf("Target(%q) = %q, want %q", tt.addr, got, tt.want)
+		}
+	}
+}
+
+func TestParseAddrs(t *testing.T) {
+	got := parseAddrs([]string{"# users", "a:8081", "", "  b:8081  ", "\t", "#c:8081"})
+	want := []resolver.Address{{Addr: "a:8081"}, {Addr: "b:8081"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+// backendServer answers GetUserByName with its own name as the user id, so
+// the caller sees which backend served a call.
+type backendServer struct {
+	usergrpc.UnimplementedUserServiceServer
+	name string
+}
+
+func (s backendServer) GetUserByName(ctx context.Context, req *usergrpc.GetUserByNameReq) (*usergrpc.GetUserByNameResponse, error) {
+	return &usergrpc.GetUserByNameResponse{User: &usergrpc.User{Id: s.name}}, nil
+}
+
+func listen(t *testing.T, name string) string {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	usergrpc.RegisterUserServiceServer(srv, backendServer{name: name})
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	return lis.Addr().String()
+}
+
+func dial(t *testing.T, target string) usergrpc.UserServiceClient {
+	t.Helper()
+
+	conn, err := grpc.Dial(target,
+		grpc.WithInsecure(),
+		grpc.WithResolvers(NewStaticBuilder(), NewFileBuilder(10*time.Millisecond)),
+		grpc.WithDefaultServiceConfig(`{"loadBalancingConfig": [{"round_robin": {}}]}`),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return usergrpc.NewUserServiceClient(conn)
+}
+
+// backends calls client until it has seen want backends or the deadline
+// passes, and returns the backends seen.
+func backends(t *testing.T, client usergrpc.UserServiceClient, want int) map[string]bool {
+	t.Helper()
+
+	seen := map[string]bool{}
+	deadline := time.Now().Add(5 * time.Second)
+	for len(seen) < want && time.Now().Before(deadline) {
+		resp, err := client.GetUserByName(context.Background(), &usergrpc.GetUserByNameReq{Name: "kasutaja"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		seen[resp.User.Id] = true
+	}
+	return seen
+}
+
+func TestStaticResolver(t *testing.T) {
+	a, b := listen(t, "a"), listen(t, "b")
+	client := dial(t, Target(a+","+b))
+
+	if got := backends(t, client, 2); !got["a"] || !got["b"] {
+		t.Errorf("calls reached %v, want both backends", got)
+	}
+}
+
+func TestFileResolver(t *testing.T) {
+	a, b := listen(t, "a"), listen(t, "b")
+
+	path := filepath.Join(t.TempDir(), "backends")
+	if err := ioutil.WriteFile(path, []byte("# users\n"+a+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	client := dial(t, (&url.URL{Scheme: FileScheme, Path: path}).String())
+
+	for i := 0; i < 10; i++ {
+		if got := backends(t, client, 1); !got["a"] {
+			t.Fatalf("calls reached %v, want only a", got)
+		}
+	}
+
+	if err := ioutil.WriteFile(path, []byte(a+"\n\n"+b+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := backends(t, client, 2); !got["a"] || !got["b"] {
+		t.Errorf("calls reached %v after adding b, want both backends", got)
+	}
+}
+
+// clientConn fails the first failures UpdateState calls and records the
+// states it accepts.
+type clientConn struct {
+	resolver.ClientConn
+	failures int
+	states   []resolver.State
+}
+
+func (cc *clientConn) UpdateState(s resolver.State) error {
+	if cc.failures > 0 {
+		cc.failures--
+		return errors.New("update failed")
+	}
+	cc.states = append(cc.states, s)
+	return nil
+}
+
+func TestFileResolverRetriesFailedUpdate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "backends")
+	if err := ioutil.WriteFile(path, []byte("a:8081\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cc := &clientConn{failures: 1}
+	r := &fileResolver{path: path, cc: cc}
+
+	if err := r.update(); err == nil {
+		t.Fatal("expected the failed update to be returned")
+	}
+	if err := r.update(); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.update(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(cc.states) != 1 {
+		t.Errorf("got %d states, want the unchanged file pushed again once after the failure", len(cc.states))
+	}
+}
@@ -0,0 +1,64 @@
+package grpcerr
+
+import (
+	"errors"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Mapping is the status code and ErrorInfo reason Err is reported with.
+type Mapping struct {
+	Err    error
+	Code   codes.Code
+	Reason string
+	// Message replaces the message of the error when set.
+	Message string
+}
+
+// Status returns the status error of the first mapping matching err with
+// errors.Is. Status errors, such as those of downstream services, are passed
+// through unchanged and other errors are reported as Internal.
+func Status(err error, domain string, mappings []Mapping) error {
+	for _, m := range mappings {
+		if !errors.Is(err, m.Err) {
+			continue
+		}
+		msg := m.Message
+		if msg == "" {
+			msg = err.Error()
+		}
+		return New(m.Code, msg, m.Reason, domain)
+	}
+
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+// New returns a status error with code and msg carrying an ErrorInfo detail
+// with reason and domain.
+func New(code codes.Code, msg, reason, domain string) error {
+	st, err := status.New(code, msg).WithDetails(&errdetails.ErrorInfo{Reason: reason, Domain: domain})
+	if err != nil {
+		return status.Error(code, msg)
+	}
+	return st.Err()
+}
+
+// Reason returns the reason of the ErrorInfo detail of err, empty when it has
+// none.
+func Reason(err error) string {
+	st, ok := status.FromError(err)
+	if !ok {
+		return ""
+	}
+	for _, d := range st.Details() {
+		if info, ok := d.(*errdetails.ErrorInfo); ok {
+			return info.Reason
+		}
+	}
+	return ""
+}
@@ -0,0 +1,80 @@
+package grpcerr
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var errTest = errors.New("test error")
+
+func TestStatus(t *testing.T) {
+	mappings := []Mapping{
+		{Err: errTest, Code: codes.InvalidArgument, Reason: "TEST"},
+	}
+
+	tests := []struct {
+		name       string
+		err        error
+		want       codes.Code
+		wantMsg    string
+		wantReason string
+	}{
+		{name: "mapped", err: errTest, want: codes.InvalidArgument, wantMsg: "test error", wantReason: "TEST"},
+		{name: "wrapped", err: fmt.Errorf("context: %w", errTest), want: codes.InvalidArgument, wantMsg: "context: test error", wantReason: "TEST"},
+		{name: "status", err: status.Error(codes.Unavailable, "down"), want: codes.Unavailable, wantMsg: "down"},
+		{name: "other", err: errors.New("boom"), want: codes.Internal, wantMsg: "boom"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := status.Convert(Status(tt.err, "test.toy", mappings))
+			if st.Code() != tt.want || st.Message() != tt.wantMsg {
+				t.Errorf("got %s %q, want %s %q", st.Code(), st.Message(), tt.want, tt.wantMsg)
+			}
+
+			var reason, domain string
+			for _, d := range st.Details() {
+				if info, ok := d.(*errdetails.ErrorInfo); ok {
+					reason, domain = info.Reason, info.Domain
+				}
+			}
+			if reason != tt.wantReason || (reason != "" && domain != "test.toy") {
+				t.Errorf("got reason %q in domain %q, want %q", reason, domain, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestStatusMessage(t *testing.T) {
+	mappings := []Mapping{{Err: errTest, Code: codes.Unauthenticated, Reason: "TEST", Message: "generic"}}
+
+	if got := status.Convert(Status(errTest, "test.toy", mappings)).Message(); got != "generic" {
+		t.Errorf("got message %q, want generic", got)
+	}
+}
+
+func TestReason(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{name: "error info", err: New(codes.Unavailable, "open", "CIRCUIT_OPEN", "test.toy"), want: "CIRCUIT_OPEN"},
+		{name: "no details", err: status.Error(codes.Unavailable, "down")},
+		{name: "not a status", err: errTest},
+		{name: "nil"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Reason(tt.err); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,7 +1,12 @@
 package jwt
 
 import (
+	"crypto"
+	"crypto/elliptic"
 	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
 	"time"
 
 	stdjwt "github.com/golang-jwt/jwt/v4"
@@ -16,6 +21,7 @@ var (
 	ErrJWTInvalid          = errors.New("Invalid JWT provided")
 	ErrJWTExpired          = errors.New("Expired JWT")
 	ErrJWTUnknownAlgorithm = errors.New("unknown JWT signing algorithm")
+	ErrJWTMissingKey       = errors.New("missing JWT key")
 )
 
 type UserClaims struct {
@@ -42,9 +48,16 @@ func NewUserClaims(username string, role string) UserClaims {
 	return uc
 }
 
+// Wrapper signs and verifies tokens with a single algorithm: HS256 with
+// Secret, or RS256, ES256 and EdDSA with SigningKey and VerifyingKey.
 type Wrapper struct {
 	Algorithm stdjwt.SigningMethod
 	Secret    string
+	// SigningKey is the private key of the asymmetric algorithms, nil when
+	// the wrapper only verifies tokens.
+	SigningKey crypto.PrivateKey
+	// VerifyingKey is the public key of the asymmetric algorithms.
+	VerifyingKey crypto.PublicKey
 }
 
 func NewHS256Wrapper(secret string) Wrapper {
@@ -54,19 +67,127 @@ func NewHS256Wrapper(secret string) Wrapper {
 	}
 }
 
+// LoadWrapper creates a Wrapper for alg, one of HS256, RS256, ES256 or EdDSA,
+// from files. For HS256 keyFile holds the secret. For the asymmetric
+// algorithms keyFile holds the PEM encoded private key and publicKeyFile the
+// PEM encoded public key; either may be empty, a wrapper without the private
+// key only verifies tokens and the public key is derived from the private key
+// when not given.
+func LoadWrapper(alg, keyFile, publicKeyFile string) (Wrapper, error) {
+	method := stdjwt.GetSigningMethod(alg)
+	switch method {
+	case stdjwt.SigningMethodHS256, stdjwt.SigningMethodRS256, stdjwt.SigningMethodES256, stdjwt.SigningMethodEdDSA:
+	default:
+		return Wrapper{}, fmt.Errorf("%w: %s", ErrJWTUnknownAlgorithm, alg)
+	}
+
+	w := Wrapper{Algorithm: method}
+	if method == stdjwt.SigningMethodHS256 {
+		if keyFile == "" {
+			return Wrapper{}, fmt.Errorf("%w: HS256 requires a secret", ErrJWTMissingKey)
+		}
+		secret, err := ioutil.ReadFile(keyFile)
+		if err != nil {
+			return Wrapper{}, err
+		}
+		w.Secret = strings.TrimSpace(string(secret))
+		if w.Secret == "" {
+			return Wrapper{}, fmt.Errorf("%w: HS256 secret file %s is empty", ErrJWTMissingKey, keyFile)
+		}
+		return w, nil
+	}
+
+	if keyFile != "" {
+		pem, err := ioutil.ReadFile(keyFile)
+		if err != nil {
+			return Wrapper{}, err
+		}
+		if w.SigningKey, err = parsePrivateKey(method, pem); err != nil {
+			return Wrapper{}, fmt.Errorf("failed to parse %s private key %s: %w", alg, keyFile, err)
+		}
+		w.VerifyingKey = w.SigningKey.(crypto.Signer).Public()
+	}
+
+	if publicKeyFile != "" {
+		pem, err := ioutil.ReadFile(publicKeyFile)
+		if err != nil {
+			return Wrapper{}, err
+		}
+		if w.VerifyingKey, err = parsePublicKey(method, pem); err != nil {
+			return Wrapper{}, fmt.Errorf("failed to parse %s public key %s: %w", alg, publicKeyFile, err)
+		}
+	}
+
+	if w.VerifyingKey == nil {
+		return Wrapper{}, fmt.Errorf("%w: %s requires a private or a public key", ErrJWTMissingKey, alg)
+	}
+	return w, nil
+}
+
+func parsePrivateKey(method stdjwt.SigningMethod, pem []byte) (crypto.PrivateKey, error) {
+	switch method {
+	case stdjwt.SigningMethodRS256:
+		return stdjwt.ParseRSAPrivateKeyFromPEM(pem)
+	case stdjwt.SigningMethodES256:
+		key, err := stdjwt.ParseECPrivateKeyFromPEM(pem)
+		if err != nil {
+			return nil, err
+		}
+		if key.Curve != elliptic.P256() {
+			return nil, errors.New("ES256 requires a P-256 key")
+		}
+		return key, nil
+	default:
+		return stdjwt.ParseEdPrivateKeyFromPEM(pem)
+	}
+}
+
+func parsePublicKey(method stdjwt.SigningMethod, pem []byte) (crypto.PublicKey, error) {
+	switch method {
+	case stdjwt.SigningMethodRS256:
+		return stdjwt.ParseRSAPublicKeyFromPEM(pem)
+	case stdjwt.SigningMethodES256:
+		key, err := stdjwt.ParseECPublicKeyFromPEM(pem)
+		if err != nil {
+			return nil, err
+		}
+		if key.Curve != elliptic.P256() {
+			return nil, errors.New("ES256 requires a P-256 key")
+		}
+		return key, nil
+	default:
+		return stdjwt.ParseEdPublicKeyFromPEM(pem)
+	}
+}
+
 func (w Wrapper) Encode(claims stdjwt.Claims) (string, error) {
+	var key interface{}
 	switch w.Algorithm {
 	case stdjwt.SigningMethodHS256:
-		token := stdjwt.NewWithClaims(w.Algorithm, claims)
-		return token.SignedString([]byte(w.Secret))
+		key = []byte(w.Secret)
+	case stdjwt.SigningMethodRS256, stdjwt.SigningMethodES256, stdjwt.SigningMethodEdDSA:
+		if w.SigningKey == nil {
+			return "", ErrJWTMissingKey
+		}
+		key = w.SigningKey
 	default:
 		return "", ErrJWTUnknownAlgorithm
 	}
+
+	token := stdjwt.NewWithClaims(w.Algorithm, claims)
+	return token.SignedString(key)
 }
 
+// Decode verifies tokenStr and decodes its claims into claims. Tokens signed
+// with another algorithm than w.Algorithm are rejected with ErrJWTAlgMismatch
+// before their signature is checked, so that a public key can never be used
+// as an HMAC secret.
 func (w Wrapper) Decode(tokenStr string, claims stdjwt.Claims) (*stdjwt.Token, error) {
 	token, err := stdjwt.ParseWithClaims(tokenStr, claims, func(t *stdjwt.Token) (interface{}, error) {
-		return []byte(w.Secret), nil
+		if t.Method.Alg() != w.Algorithm.Alg() {
+			return nil, ErrJWTAlgMismatch
+		}
+		return w.verifyingKey()
 	})
 
 	if err != nil {
@@ -74,6 +195,10 @@ func (w Wrapper) Decode(tokenStr string, claims stdjwt.Claims) (*stdjwt.Token, e
 			if e.Errors == stdjwt.ValidationErrorExpired {
 				return nil, ErrJWTExpired
 			}
+			switch e.Inner {
+			case ErrJWTAlgMismatch, ErrJWTMissingKey, ErrJWTUnknownAlgorithm:
+				return nil, e.Inner
+			}
 		}
 		return nil, err
 	}
@@ -82,9 +207,29 @@ func (w Wrapper) Decode(tokenStr string, claims stdjwt.Claims) (*stdjwt.Token, e
 		return nil, ErrJWTInvalid
 	}
 
-	if token.Method.Alg() != w.Algorithm.Alg() {
-		return nil, ErrJWTAlgMismatch
+	return token, nil
+}
+
+// verifyingKey returns the key verifying the signatures of w.Algorithm.
+func (w Wrapper) verifyingKey() (interface{}, error) {
+	switch w.Algorithm {
+	case stdjwt.SigningMethodHS256:
+		return []byte(w.Secret), nil
+	case stdjwt.SigningMethodRS256, stdjwt.SigningMethodES256, stdjwt.SigningMethodEdDSA:
+		if w.VerifyingKey == nil {
+			return nil, ErrJWTMissingKey
+		}
+		return w.VerifyingKey, nil
+	default:
+		return nil, ErrJWTUnknownAlgorithm
 	}
+}
 
-	return token, nil
+// DecodeUserClaims decodes and verifies a token carrying UserClaims.
+func (w Wrapper) DecodeUserClaims(tokenStr string) (UserClaims, error) {
+	claims := UserClaims{RegisteredClaims: &stdjwt.RegisteredClaims{}}
+	if _, err := w.Decode(tokenStr, &claims); err != nil {
+		return UserClaims{}, err
+	}
+	return claims, nil
 }
@@ -0,0 +1,179 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadWrapperHS256(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	w, err := LoadWrapper("HS256", write("secret", "s3cret\n"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.Secret != "s3cret" {
+		t.Errorf("got secret %q, want s3cret", w.Secret)
+	}
+
+	for name, path := range map[string]string{
+		"no file":    "",
+		"empty":      write("empty", ""),
+		"whitespace": write("whitespace", " \n\t\n"),
+	} {
+		if _, err := LoadWrapper("HS256", path, ""); !errors.Is(err, ErrJWTMissingKey) {
+			t.Errorf("%s: got %v, want ErrJWTMissingKey", name, err)
+		}
+	}
+}
+
+// writeKeys generates a key for alg and writes it and its public key PEM
+// encoded to dir, returning the paths of both files.
+func writeKeys(t *testing.T, dir, alg string, curve elliptic.Curve) (string, string) {
+	t.Helper()
+
+	var key crypto.Signer
+	var err error
+	switch alg {
+	case "RS256":
+		key, err = rsa.GenerateKey(rand.Reader, 2048)
+	case "ES256":
+		key, err = ecdsa.GenerateKey(curve, rand.Reader)
+	case "EdDSA":
+		_, key, err = ed25519.GenerateKey(rand.Reader)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	priv, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	write := func(name, typ string, der []byte) string {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der}), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	return write(alg+".key", "PRIVATE KEY", priv), write(alg+".pub", "PUBLIC KEY", pub)
+}
+
+func TestLoadWrapperAsymmetric(t *testing.T) {
+	for _, alg := range []string{"RS256", "ES256", "EdDSA"} {
+		t.Run(alg, func(t *testing.T) {
+			keyFile, publicKeyFile := writeKeys(t, t.TempDir(), alg, elliptic.P256())
+
+			signer, err := LoadWrapper(alg, keyFile, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			verifier, err := LoadWrapper(alg, "", publicKeyFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			token, err := signer.Encode(NewUserClaims("kasutaja", "admin"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for name, w := range map[string]Wrapper{"signer": signer, "verifier": verifier} {
+				claims, err := w.DecodeUserClaims(token)
+				if err != nil {
+					t.Fatalf("%s: %v", name, err)
+				}
+				if claims.Username != "kasutaja" || claims.Role != "admin" {
+					t.Errorf("%s: got %+v", name, claims)
+				}
+			}
+
+			if _, err := verifier.Encode(NewUserClaims("kasutaja", "admin")); !errors.Is(err, ErrJWTMissingKey) {
+				t.Errorf("got %v encoding with only the public key, want ErrJWTMissingKey", err)
+			}
+
+			// A token signed by another key of the same algorithm.
+			otherKeyFile, _ := writeKeys(t, t.TempDir(), alg, elliptic.P256())
+			other, err := LoadWrapper(alg, otherKeyFile, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			forged, err := other.Encode(NewUserClaims("kasutaja", "admin"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := verifier.DecodeUserClaims(forged); err == nil {
+				t.Error("got a token signed by another key accepted")
+			}
+		})
+	}
+}
+
+func TestDecodeAlgMismatch(t *testing.T) {
+	keyFile, publicKeyFile := writeKeys(t, t.TempDir(), "RS256", nil)
+	rs, err := LoadWrapper("RS256", keyFile, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKey, err := ioutil.ReadFile(publicKeyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		signer  Wrapper
+		decoder Wrapper
+	}{
+		{name: "HS256 token, RS256 wrapper", signer: NewHS256Wrapper("secret"), decoder: rs},
+		// The public key is not secret, it must not verify HMAC signatures.
+		{name: "HS256 token signed with the public key", signer: NewHS256Wrapper(string(publicKey)), decoder: rs},
+		{name: "RS256 token, HS256 wrapper", signer: rs, decoder: NewHS256Wrapper("secret")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := tt.signer.Encode(NewUserClaims("kasutaja", "admin"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := tt.decoder.DecodeUserClaims(token); !errors.Is(err, ErrJWTAlgMismatch) {
+				t.Errorf("got %v, want ErrJWTAlgMismatch", err)
+			}
+		})
+	}
+}
+
+func TestLoadWrapperES256Curve(t *testing.T) {
+	keyFile, publicKeyFile := writeKeys(t, t.TempDir(), "ES256", elliptic.P384())
+
+	if _, err := LoadWrapper("ES256", keyFile, ""); err == nil || !strings.Contains(err.Error(), "P-256") {
+		t.Errorf("got %v for a P-384 private key, want it rejected", err)
+	}
+	if _, err := LoadWrapper("ES256", "", publicKeyFile); err == nil || !strings.Contains(err.Error(), "P-256") {
+		t.Errorf("got %v for a P-384 public key, want it rejected", err)
+	}
+}
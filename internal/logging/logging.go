@@ -0,0 +1,83 @@
+package logging
+
+import (
+	"context"
+	"flag"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Logger writes JSON records and adds the trace_id and span_id of the span in
+// the context to every record, so logs can be joined with traces.
+type Logger struct {
+	z *zap.Logger
+}
+
+// RegisterFlags binds the -logLevel flag to fs.
+func RegisterFlags(fs *flag.FlagSet) *zapcore.Level {
+	level := zapcore.InfoLevel
+	fs.Var(&level, "logLevel", "minimum log level, one of debug, info, warn, error")
+	return &level
+}
+
+func New(service string, level zapcore.Level) (Logger, error) {
+	cfg := zap.NewProductionConfig()
+	cfg.Level = zap.NewAtomicLevelAt(level)
+	cfg.DisableStacktrace = true
+	cfg.EncoderConfig.TimeKey = "time"
+	cfg.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+
+	z, err := cfg.Build(zap.AddCallerSkip(1))
+	if err != nil {
+		return Logger{}, err
+	}
+
+	return Logger{z: z.With(zap.String("service", service))}, nil
+}
+
+func NewNop() Logger {
+	return Logger{z: zap.NewNop()}
+}
+
+func (l Logger) With(fields ...zap.Field) Logger {
+	return Logger{z: l.z.With(fields...)}
+}
+
+func (l Logger) Debug(ctx context.Context, msg string, fields ...zap.Field) {
+	l.z.Debug(msg, withTrace(ctx, fields)...)
+}
+
+func (l Logger) Info(ctx context.Context, msg string, fields ...zap.Field) {
+	l.z.Info(msg, withTrace(ctx, fields)...)
+}
+
+func (l Logger) Warn(ctx context.Context, msg string, fields ...zap.Field) {
+	l.z.Warn(msg, withTrace(ctx, fields)...)
+}
+
+func (l Logger) Error(ctx context.Context, msg string, fields ...zap.Field) {
+	l.z.Error(msg, withTrace(ctx, fields)...)
+}
+
+// Fatal logs at fatal level and exits the process.
+func (l Logger) Fatal(ctx context.Context, msg string, fields ...zap.Field) {
+	l.z.Fatal(msg, withTrace(ctx, fields)...)
+}
+
+func (l Logger) Sync() error {
+	return l.z.Sync()
+}
+
+func withTrace(ctx context.Context, fields []zap.Field) []zap.Field {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return fields
+	}
+
+	return append(fields,
+		zap.String("trace_id", sc.TraceID().String()),
+		zap.String("span_id", sc.SpanID().String()),
+	)
+}
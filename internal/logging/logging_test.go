@@ -0,0 +1,63 @@
+package logging
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestTraceFields(t *testing.T) {
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{0x01, 0x02},
+		SpanID:  trace.SpanID{0x03},
+	})
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want map[string]interface{}
+	}{
+		{
+			name: "span",
+			ctx:  trace.ContextWithSpanContext(context.Background(), sc),
+			want: map[string]interface{}{"user": "kasutaja", "trace_id": sc.TraceID().String(), "span_id": sc.SpanID().String()},
+		},
+		{
+			name: "no span",
+			ctx:  context.Background(),
+			want: map[string]interface{}{"user": "kasutaja"},
+		},
+		{
+			name: "invalid span",
+			ctx:  trace.ContextWithSpanContext(context.Background(), trace.SpanContext{}),
+			want: map[string]interface{}{"user": "kasutaja"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, logs := observer.New(zapcore.DebugLevel)
+			l := Logger{z: zap.New(core)}
+
+			l.Info(tt.ctx, "logged in", zap.String("user", "kasutaja"))
+
+			entries := logs.All()
+			if len(entries) != 1 {
+				t.Fatalf("got %d entries, want 1", len(entries))
+			}
+			got := entries[0].ContextMap()
+			if len(got) != len(tt.want) {
+				t.Errorf("got fields %v, want %v", got, tt.want)
+			}
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Errorf("got %s=%v, want %v", k, got[k], v)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/felixge/httpsnoop"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// Metrics holds the request count, error count and latency (RED) metrics of
+// a binary and the registry they are exposed from.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests *prometheus.CounterVec
+	httpErrors   *prometheus.CounterVec
+	httpDuration *prometheus.HistogramVec
+
+	grpcRequests *prometheus.CounterVec
+	grpcErrors   *prometheus.CounterVec
+	grpcDuration *prometheus.HistogramVec
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_server_requests_total",
+			Help: "Number of HTTP requests handled.",
+		}, []string{"route", "method", "code"}),
+		httpErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_server_errors_total",
+			Help: "Number of HTTP requests answered with a 5xx status code.",
+		}, []string{"route", "method", "code"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_server_request_duration_seconds",
+			Help:    "Latency of HTTP requests.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"route", "method"}),
+		grpcRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "grpc_server_handled_total",
+			Help: "Number of RPCs handled.",
+		}, []string{"grpc_service", "grpc_method", "grpc_code"}),
+		grpcErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "grpc_server_errors_total",
+			Help: "Number of RPCs completed with a non-OK status code.",
+		}, []string{"grpc_service", "grpc_method", "grpc_code"}),
+		grpcDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "grpc_server_handling_seconds",
+			Help:    "Latency of RPCs.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"grpc_service", "grpc_method"}),
+	}
+
+	m.registry.MustRegister(
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+		m.httpRequests, m.httpErrors, m.httpDuration,
+		m.grpcRequests, m.grpcErrors, m.grpcDuration,
+	)
+
+	return m
+}
+
+// Register adds collectors to the registry served by Handler.
+func (m *Metrics) Register(cs ...prometheus.Collector) {
+	m.registry.MustRegister(cs...)
+}
+
+// Handler serves the registry in the Prometheus exposition format. OpenMetrics
+// is negotiated when the scraper asks for it, which is the only format that
+// carries exemplars.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{EnableOpenMetrics: true})
+}
+
+// ListenAndServe serves Handler on addr under /metrics.
+func (m *Metrics) ListenAndServe(addr string) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", m.Handler())
+	return http.ListenAndServe(addr, mux)
+}
+
+// HTTPHandler records the RED metrics of h under route.
+func (m *Metrics) HTTPHandler(route string, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		snoop := httpsnoop.CaptureMetrics(h, w, r)
+
+		code := strconv.Itoa(snoop.Code)
+		m.httpRequests.WithLabelValues(route, r.Method, code).Inc()
+		if snoop.Code >= http.StatusInternalServerError {
+			m.httpErrors.WithLabelValues(route, r.Method, code).Inc()
+		}
+		observe(r.Context(), m.httpDuration.WithLabelValues(route, r.Method), snoop.Duration.Seconds())
+	})
+}
+
+func (m *Metrics) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		m.observeRPC(ctx, info.FullMethod, start, err)
+		return resp, err
+	}
+}
+
+func (m *Metrics) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		m.observeRPC(ss.Context(), info.FullMethod, start, err)
+		return err
+	}
+}
+
+func (m *Metrics) observeRPC(ctx context.Context, fullMethod string, start time.Time, err error) {
+	service, method := splitMethod(fullMethod)
+	code := status.Code(err)
+
+	m.grpcRequests.WithLabelValues(service, method, code.String()).Inc()
+	if err != nil {
+		m.grpcErrors.WithLabelValues(service, method, code.String()).Inc()
+	}
+	observe(ctx, m.grpcDuration.WithLabelValues(service, method), time.Since(start).Seconds())
+}
+
+// observe records v on o with the trace ID of the span in ctx as exemplar, so
+// a latency bucket links to a trace of a request that fell in it. Unsampled
+// spans are left out as their traces are never exported.
+func observe(ctx context.Context, o prometheus.Observer, v float64) {
+	sc := trace.SpanContextFromContext(ctx)
+	eo, ok := o.(prometheus.ExemplarObserver)
+	if !ok || !sc.IsSampled() {
+		o.Observe(v)
+		return
+	}
+
+	eo.ObserveWithExemplar(v, prometheus.Labels{"trace_id": sc.TraceID().String()})
+}
+
+// splitMethod splits a gRPC full method name, "/package.Service/Method", into
+// its service and method.
+func splitMethod(fullMethod string) (string, string) {
+	fullMethod = strings.TrimPrefix(fullMethod, "/")
+	if i := strings.LastIndex(fullMethod, "/"); i >= 0 {
+		return fullMethod[:i], fullMethod[i+1:]
+	}
+	return "unknown", fullMethod
+}
@@ -0,0 +1,175 @@
+package metrics
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestHTTPHandler(t *testing.T) {
+	m := New()
+	h := m.HTTPHandler("/auth", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("fail") != "" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+
+	for _, target := range []string{"/auth", "/auth", "/auth?fail=1"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, target, nil))
+	}
+
+	tests := []struct {
+		name string
+		got  float64
+		want float64
+	}{
+		{name: "200 requests", got: testutil.ToFloat64(m.httpRequests.WithLabelValues("/auth", "POST", "200")), want: 2},
+		{name: "503 requests", got: testutil.ToFloat64(m.httpRequests.WithLabelValues("/auth", "POST", "503")), want: 1},
+		{name: "503 errors", got: testutil.ToFloat64(m.httpErrors.WithLabelValues("/auth", "POST", "503")), want: 1},
+		{name: "200 errors", got: testutil.ToFloat64(m.httpErrors.WithLabelValues("/auth", "POST", "200")), want: 0},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+
+	if got := testutil.CollectAndCount(m.httpDuration); got != 1 {
+		t.Errorf("got %d duration series, want 1", got)
+	}
+}
+
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s serverStream) Context() context.Context { return s.ctx }
+
+func TestInterceptors(t *testing.T) {
+	m := New()
+	ctx := context.Background()
+
+	unary := m.UnaryServerInterceptor()
+	info := &grpc.UnaryServerInfo{FullMethod: "/toy.UserService/GetUserByName"}
+	unary(ctx, nil, info, func(context.Context, interface{}) (interface{}, error) { return nil, nil })
+	unary(ctx, nil, info, func(context.Context, interface{}) (interface{}, error) {
+		return nil, status.Error(codes.NotFound, "user not found")
+	})
+
+	stream := m.StreamServerInterceptor()
+	stream(nil, serverStream{ctx: ctx}, &grpc.StreamServerInfo{FullMethod: "/toy.Calculator/Evaluate"}, func(interface{}, grpc.ServerStream) error {
+		return status.Error(codes.InvalidArgument, "division by zero")
+	})
+
+	tests := []struct {
+		name string
+		got  float64
+		want float64
+	}{
+		{name: "unary OK", got: testutil.ToFloat64(m.grpcRequests.WithLabelValues("toy.UserService", "GetUserByName", "OK")), want: 1},
+		{name: "unary NotFound", got: testutil.ToFloat64(m.grpcRequests.WithLabelValues("toy.UserService", "GetUserByName", "NotFound")), want: 1},
+		{name: "unary errors", got: testutil.ToFloat64(m.grpcErrors.WithLabelValues("toy.UserService", "GetUserByName", "NotFound")), want: 1},
+		{name: "unary OK errors", got: testutil.ToFloat64(m.grpcErrors.WithLabelValues("toy.UserService", "GetUserByName", "OK")), want: 0},
+		{name: "stream", got: testutil.ToFloat64(m.grpcRequests.WithLabelValues("toy.Calculator", "Evaluate", "InvalidArgument")), want: 1},
+		{name: "stream errors", got: testutil.ToFloat64(m.grpcErrors.WithLabelValues("toy.Calculator", "Evaluate", "InvalidArgument")), want: 1},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+
+	if got := testutil.CollectAndCount(m.grpcDuration); got != 2 {
+		t.Errorf("got %d duration series, want 2", got)
+	}
+}
+
+func TestSplitMethod(t *testing.T) {
+	tests := []struct {
+		fullMethod      string
+		service, method string
+	}{
+		{fullMethod: "/toy.UserService/GetUserByName", service: "toy.UserService", method: "GetUserByName"},
+		{fullMethod: "Method", service: "unknown", method: "Method"},
+	}
+	for _, tt := range tests {
+		service, method := splitMethod(tt.fullMethod)
+		if service != tt.service || method != tt.method {
+			t.Errorf("%s: got %s %s, want %s %s", tt.fullMethod, service, method, tt.service, tt.method)
+		}
+	}
+}
+
+func TestObserveExemplar(t *testing.T) {
+	traceID := trace.TraceID{0x01, 0x02}
+	spanContext := func(flags trace.TraceFlags) context.Context {
+		sc := trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: trace.SpanID{1}, TraceFlags: flags})
+		return trace.ContextWithSpanContext(context.Background(), sc)
+	}
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want string
+	}{
+		{name: "sampled", ctx: spanContext(trace.FlagsSampled), want: traceID.String()},
+		{name: "unsampled", ctx: spanContext(0)},
+		{name: "no span", ctx: context.Background()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "test_seconds", Buckets: []float64{1}})
+			observe(tt.ctx, h, 0.5)
+
+			var metric dto.Metric
+			if err := h.Write(&metric); err != nil {
+				t.Fatal(err)
+			}
+
+			var got string
+			if ex := metric.GetHistogram().GetBucket()[0].GetExemplar(); ex != nil {
+				for _, l := range ex.GetLabel() {
+					if l.GetName() == "trace_id" {
+						got = l.GetValue()
+					}
+				}
+			}
+			if got != tt.want {
+				t.Errorf("got exemplar trace_id %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandlerServesExemplars(t *testing.T) {
+	m := New()
+	sc := trace.NewSpanContext(trace.SpanContextConfig{TraceID: trace.TraceID{0x0a}, SpanID: trace.SpanID{1}, TraceFlags: trace.FlagsSampled})
+	r := httptest.NewRequest(http.MethodGet, "/auth", nil)
+	r = r.WithContext(trace.ContextWithSpanContext(r.Context(), sc))
+	m.HTTPHandler("/auth", http.NotFoundHandler()).ServeHTTP(httptest.NewRecorder(), r)
+
+	scrape := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	scrape.Header.Set("Accept", "application/openmetrics-text; version=0.0.1")
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, scrape)
+
+	body, _ := ioutil.ReadAll(w.Body)
+	if want := `# {trace_id="` + sc.TraceID().String() + `"}`; !strings.Contains(string(body), want) {
+		t.Errorf("OpenMetrics output does not contain the exemplar %s", want)
+	}
+}
@@ -0,0 +1,257 @@
+package clients
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+	"toy/internal/grpcerr"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// CallPolicy controls the deadline, retries and hedging of unary calls.
+type CallPolicy struct {
+	// Timeout is the deadline of a call, covering all of its attempts.
+	Timeout time.Duration
+	// MaxAttempts includes the original attempt, retries and hedged
+	// attempts are disabled when it is 1.
+	MaxAttempts       int
+	InitialBackoff    time.Duration
+	MaxBackoff        time.Duration
+	BackoffMultiplier float64
+	// HedgingDelay enables hedging of the methods in Config.HedgedMethods
+	// when set: another attempt is started whenever the previous one has not
+	// completed within the delay, and the first successful attempt wins.
+	HedgingDelay time.Duration
+}
+
+func DefaultCallPolicy() CallPolicy {
+	return CallPolicy{
+		Timeout:           10 * time.Second,
+		MaxAttempts:       3,
+		InitialBackoff:    100 * time.Millisecond,
+		MaxBackoff:        time.Second,
+		BackoffMultiplier: 2,
+	}
+}
+
+// MethodTimeouts overrides the CallPolicy timeout per full method name, e.g.
+// "/toy.UserService/GetUserByName". It is a flag.Value accepting
+// method=duration pairs.
+type MethodTimeouts map[string]time.Duration
+
+func (mt MethodTimeouts) String() string {
+	pairs := make([]string, 0, len(mt))
+	for method, timeout := range mt {
+		pairs = append(pairs, method+"="+timeout.String())
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func (mt MethodTimeouts) Set(s string) error {
+	for _, pair := range strings.Split(s, ",") {
+		i := strings.LastIndex(pair, "=")
+		if i < 0 {
+			return fmt.Errorf("invalid method timeout %q, want method=duration", pair)
+		}
+		timeout, err := time.ParseDuration(pair[i+1:])
+		if err != nil {
+			return err
+		}
+		mt[pair[:i]] = timeout
+	}
+	return nil
+}
+
+// HedgedMethods is the set of full method names that may be hedged, which
+// must only contain idempotent methods. It is a flag.Value accepting comma
+// separated method names.
+type HedgedMethods map[string]bool
+
+func (hm HedgedMethods) String() string {
+	methods := make([]string, 0, len(hm))
+	for method, ok := range hm {
+		if ok {
+			methods = append(methods, method)
+		}
+	}
+	sort.Strings(methods)
+	return strings.Join(methods, ",")
+}
+
+func (hm HedgedMethods) Set(s string) error {
+	for _, method := range strings.Split(s, ",") {
+		if !strings.HasPrefix(method, "/") || strings.Count(method, "/") != 2 {
+			return fmt.Errorf("invalid method %q, want /package.Service/Method", method)
+		}
+		hm[method] = true
+	}
+	return nil
+}
+
+func (c Config) callPolicy(method string) CallPolicy {
+	p := c.CallPolicy
+	if timeout, ok := c.MethodTimeouts[method]; ok {
+		p.Timeout = timeout
+	}
+	if !c.HedgedMethods[method] {
+		p.HedgingDelay = 0
+	}
+	if p.MaxAttempts < 1 {
+		p.MaxAttempts = 1
+	}
+	return p
+}
+
+// backoff returns the delay before the retry following attempt, with the
+// same +-20% jitter gRPC uses for reconnects.
+func (p CallPolicy) backoff(attempt int) time.Duration {
+	delay := float64(p.InitialBackoff)
+	for i := 1; i < attempt; i++ {
+		delay *= p.BackoffMultiplier
+	}
+	if max := float64(p.MaxBackoff); max > 0 && delay > max {
+		delay = max
+	}
+	delay *= 0.8 + 0.4*rand.Float64()
+	return time.Duration(delay)
+}
+
+// retryable reports whether err is Unavailable, except for the rejections of
+// an open circuit breaker, here or in a downstream service, which would only
+// be rejected again.
+func retryable(err error) bool {
+	return status.Code(err) == codes.Unavailable && grpcerr.Reason(err) != circuitOpenReason
+}
+
+// unaryCallPolicy applies the CallPolicy of the method. It runs in front of
+// the otelgrpc interceptor so that every attempt is traced as its own client
+// span, and records retries and hedges as events on the caller's span.
+func (c Config) unaryCallPolicy() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		p := c.callPolicy(method)
+		if p.Timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, p.Timeout)
+			defer cancel()
+		}
+
+		if msg, ok := reply.(proto.Message); ok && p.HedgingDelay > 0 && p.MaxAttempts > 1 {
+			return hedge(ctx, p, method, req, msg, cc, invoker, opts...)
+		}
+		return retry(ctx, p, method, req, reply, cc, invoker, opts...)
+	}
+}
+
+func retry(ctx context.Context, p CallPolicy, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	span := trace.SpanFromContext(ctx)
+
+	for attempt := 1; ; attempt++ {
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		if err == nil || !retryable(err) || attempt >= p.MaxAttempts {
+			return err
+		}
+
+		if !sleep(ctx, span, method, attempt+1, p.backoff(attempt), err) {
+			return err
+		}
+	}
+}
+
+// sleep records the retry of method as attempt, caused by err, on span and
+// waits delay. It returns false when ctx is done first.
+func sleep(ctx context.Context, span trace.Span, method string, attempt int, delay time.Duration, err error) bool {
+	span.AddEvent("retry", trace.WithAttributes(
+		attribute.String("rpc.method", method),
+		attribute.Int("rpc.attempt", attempt),
+		attribute.String("rpc.retry.backoff", delay.String()),
+		attribute.String("rpc.retry.cause", err.Error()),
+	))
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+func hedge(ctx context.Context, p CallPolicy, method string, req interface{}, reply proto.Message, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	span := trace.SpanFromContext(ctx)
+
+	// Pending attempts are canceled once one of them wins.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		reply proto.Message
+		err   error
+	}
+	results := make(chan result, p.MaxAttempts)
+
+	started := 0
+	start := func() {
+		started++
+		r := proto.Clone(reply)
+		go func() {
+			results <- result{reply: r, err: invoker(ctx, method, req, r, cc, opts...)}
+		}()
+	}
+
+	timer := time.NewTimer(p.HedgingDelay)
+	defer timer.Stop()
+
+	start()
+	for done := 0; ; {
+		select {
+		case <-timer.C:
+			if started < p.MaxAttempts {
+				span.AddEvent("hedge", trace.WithAttributes(
+					attribute.String("rpc.method", method),
+					attribute.Int("rpc.attempt", started+1),
+				))
+				start()
+				timer.Reset(p.HedgingDelay)
+			}
+		case res := <-results:
+			done++
+			if res.err == nil {
+				proto.Reset(reply)
+				proto.Merge(reply, res.reply)
+				return nil
+			}
+			if !retryable(res.err) {
+				return res.err
+			}
+			// All attempts failed, the next one backs off like a retry.
+			if done == started {
+				if started >= p.MaxAttempts || !sleep(ctx, span, method, started+1, p.backoff(started), res.err) {
+					return res.err
+				}
+				start()
+				resetTimer(timer, p.HedgingDelay)
+			}
+		}
+	}
+}
+
+func resetTimer(t *time.Timer, d time.Duration) {
+	if !t.Stop() {
+		select {
+		case <-t.C:
+		default:
+		}
+	}
+	t.Reset(d)
+}
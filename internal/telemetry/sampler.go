@@ -0,0 +1,163 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	samplerEnv    = "OTEL_TRACES_SAMPLER"
+	samplerArgEnv = "OTEL_TRACES_SAMPLER_ARG"
+)
+
+const (
+	SamplerAlwaysOn                = "always_on"
+	SamplerAlwaysOff               = "always_off"
+	SamplerTraceIDRatio            = "traceidratio"
+	SamplerParentBasedAlwaysOn     = "parentbased_always_on"
+	SamplerParentBasedAlwaysOff    = "parentbased_always_off"
+	SamplerParentBasedTraceIDRatio = "parentbased_traceidratio"
+)
+
+// NewSampler builds a sampler from the names defined by the OTEL_TRACES_SAMPLER
+// specification. arg is the sampling ratio for the ratio based samplers and
+// defaults to 1.0 when empty.
+func NewSampler(name, arg string) (sdktrace.Sampler, error) {
+	ratio := 1.0
+	if arg != "" && (name == SamplerTraceIDRatio || name == SamplerParentBasedTraceIDRatio) {
+		r, err := strconv.ParseFloat(arg, 64)
+		if err != nil || r < 0 || r > 1 {
+			return nil, fmt.Errorf("invalid sampler ratio %q", arg)
+		}
+		ratio = r
+	}
+
+	switch name {
+	case SamplerAlwaysOn:
+		return sdktrace.AlwaysSample(), nil
+	case SamplerAlwaysOff:
+		return sdktrace.NeverSample(), nil
+	case SamplerTraceIDRatio:
+		return sdktrace.TraceIDRatioBased(ratio), nil
+	case SamplerParentBasedAlwaysOn:
+		return sdktrace.ParentBased(sdktrace.AlwaysSample()), nil
+	case SamplerParentBasedAlwaysOff:
+		return sdktrace.ParentBased(sdktrace.NeverSample()), nil
+	case SamplerParentBasedTraceIDRatio:
+		return sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio)), nil
+	default:
+		return nil, fmt.Errorf("unknown sampler %q", name)
+	}
+}
+
+// samplerFromEnv returns the sampler configured by OTEL_TRACES_SAMPLER and
+// OTEL_TRACES_SAMPLER_ARG, or nil when the variable is unset.
+func samplerFromEnv() (sdktrace.Sampler, error) {
+	name := os.Getenv(samplerEnv)
+	if name == "" {
+		return nil, nil
+	}
+	return NewSampler(name, os.Getenv(samplerArgEnv))
+}
+
+// SamplingRule applies to root spans named Route, or whose http.target or
+// http.route attribute equals Route.
+type SamplingRule struct {
+	Route string
+	// Ratio of matching traces to sample.
+	Ratio float64
+	// SampleErrors records the matching spans that Ratio left out so that
+	// they are still exported when they end with an error status. Their
+	// children follow the unsampled decision, so only the matching span is
+	// exported; TailSampler with an ErrorPolicy keeps whole failed traces.
+	SampleErrors bool
+}
+
+type ruleSampler struct {
+	rules    []SamplingRule
+	fallback sdktrace.Sampler
+}
+
+// RuleBased returns a sampler that samples root spans matching one of rules
+// with the ratio of the first matching rule. Spans with a parent follow the
+// decision of the parent, so that a trace is sampled as a whole, and root
+// spans that match no rule are delegated to fallback.
+func RuleBased(fallback sdktrace.Sampler, rules ...SamplingRule) sdktrace.Sampler {
+	return ruleSampler{rules: rules, fallback: sdktrace.ParentBased(fallback)}
+}
+
+func (rs ruleSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	psc := trace.SpanContextFromContext(p.ParentContext)
+	if psc.IsValid() {
+		return rs.fallback.ShouldSample(p)
+	}
+
+	for _, rule := range rs.rules {
+		if !rule.matches(p) {
+			continue
+		}
+
+		res := sdktrace.TraceIDRatioBased(rule.Ratio).ShouldSample(p)
+		if res.Decision == sdktrace.Drop && rule.SampleErrors {
+			res.Decision = sdktrace.RecordOnly
+		}
+		return res
+	}
+
+	return rs.fallback.ShouldSample(p)
+}
+
+func (rs ruleSampler) Description() string {
+	return fmt.Sprintf("RuleBased{rules:%v,fallback:%s}", rs.rules, rs.fallback.Description())
+}
+
+func (r SamplingRule) matches(p sdktrace.SamplingParameters) bool {
+	if p.Name == r.Route {
+		return true
+	}
+
+	for _, attr := range p.Attributes {
+		if (attr.Key == semconv.HTTPTargetKey || attr.Key == semconv.HTTPRouteKey) && attr.Value.AsString() == r.Route {
+			return true
+		}
+	}
+
+	return false
+}
+
+// errorSpanProcessor forwards spans that were recorded but not sampled to
+// next when they end with an error status.
+type errorSpanProcessor struct {
+	next sdktrace.SpanProcessor
+}
+
+func (ep errorSpanProcessor) OnStart(context.Context, sdktrace.ReadWriteSpan) {}
+
+func (ep errorSpanProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	if s.SpanContext().IsSampled() || s.Status().Code != codes.Error {
+		return
+	}
+	ep.next.OnEnd(sampledSpan{s})
+}
+
+func (ep errorSpanProcessor) Shutdown(context.Context) error { return nil }
+
+func (ep errorSpanProcessor) ForceFlush(context.Context) error { return nil }
+
+// sampledSpan marks a recorded span as sampled so exporting span processors
+// do not drop it.
+type sampledSpan struct {
+	sdktrace.ReadOnlySpan
+}
+
+func (s sampledSpan) SpanContext() trace.SpanContext {
+	sc := s.ReadOnlySpan.SpanContext()
+	return sc.WithTraceFlags(sc.TraceFlags().WithSampled(true))
+}
@@ -0,0 +1,117 @@
+package telemetry
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestRuleBasedMatching(t *testing.T) {
+	sampler := RuleBased(sdktrace.AlwaysSample(),
+		SamplingRule{Route: "/auth", Ratio: 0},
+		SamplingRule{Route: "/errors", Ratio: 0, SampleErrors: true},
+	)
+
+	tests := []struct {
+		name  string
+		span  string
+		attrs []attribute.KeyValue
+		want  sdktrace.SamplingDecision
+	}{
+		{name: "span name", span: "/auth", want: sdktrace.Drop},
+		{name: "http.target", span: "HTTP POST", attrs: []attribute.KeyValue{semconv.HTTPTargetKey.String("/auth")}, want: sdktrace.Drop},
+		{name: "http.route", span: "HTTP POST", attrs: []attribute.KeyValue{semconv.HTTPRouteKey.String("/auth")}, want: sdktrace.Drop},
+		{name: "sample errors", span: "/errors", want: sdktrace.RecordOnly},
+		{name: "no match", span: "/other", attrs: []attribute.KeyValue{semconv.HTTPTargetKey.String("/auth/x")}, want: sdktrace.RecordAndSample},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := sampler.ShouldSample(sdktrace.SamplingParameters{
+				ParentContext: context.Background(),
+				TraceID:       trace.TraceID{1},
+				Name:          tt.span,
+				Attributes:    tt.attrs,
+			})
+			if res.Decision != tt.want {
+				t.Errorf("got decision %v, want %v", res.Decision, tt.want)
+			}
+		})
+	}
+}
+
+func TestRuleBasedFollowsParent(t *testing.T) {
+	sampler := RuleBased(sdktrace.AlwaysSample(), SamplingRule{Route: "/auth", Ratio: 0})
+
+	parent := func(sampled bool) context.Context {
+		flags := trace.TraceFlags(0).WithSampled(sampled)
+		sc := trace.NewSpanContext(trace.SpanContextConfig{TraceID: trace.TraceID{1}, SpanID: trace.SpanID{1}, TraceFlags: flags})
+		return trace.ContextWithSpanContext(context.Background(), sc)
+	}
+
+	tests := []struct {
+		name    string
+		sampled bool
+		span    string
+		want    sdktrace.SamplingDecision
+	}{
+		{name: "unsampled parent", sampled: false, span: "child", want: sdktrace.Drop},
+		{name: "sampled parent", sampled: true, span: "child", want: sdktrace.RecordAndSample},
+		{name: "rules skip child spans", sampled: true, span: "/auth", want: sdktrace.RecordAndSample},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := sampler.ShouldSample(sdktrace.SamplingParameters{
+				ParentContext: parent(tt.sampled),
+				TraceID:       trace.TraceID{1},
+				Name:          tt.span,
+			})
+			if res.Decision != tt.want {
+				t.Errorf("got decision %v, want %v", res.Decision, tt.want)
+			}
+		})
+	}
+}
+
+func TestErrorSpansOfRecordOnlyTracesAreExported(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	ssp := sdktrace.NewSimpleSpanProcessor(exporter)
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSampler(RuleBased(sdktrace.AlwaysSample(), SamplingRule{Route: "/auth", Ratio: 0, SampleErrors: true})),
+		sdktrace.WithSpanProcessor(ssp),
+		sdktrace.WithSpanProcessor(errorSpanProcessor{next: ssp}),
+	)
+	defer tp.Shutdown(context.Background())
+	tracer := tp.Tracer("test")
+
+	ctx, ok := tracer.Start(context.Background(), "/auth")
+	_, child := tracer.Start(ctx, "child")
+	child.End()
+	ok.End()
+
+	if got := len(exporter.GetSpans()); got != 0 {
+		t.Fatalf("exported %d spans of a successful dropped trace, want 0", got)
+	}
+	if child.IsRecording() || child.SpanContext().IsSampled() {
+		t.Error("child of a dropped root span is recorded")
+	}
+
+	_, failed := tracer.Start(context.Background(), "/auth")
+	failed.SetStatus(codes.Error, "boom")
+	failed.End()
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("exported %d spans, want the failed root span", len(spans))
+	}
+	if !spans[0].SpanContext.IsSampled() {
+		t.Error("exported span is not marked as sampled")
+	}
+}
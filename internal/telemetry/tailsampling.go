@@ -0,0 +1,229 @@
+package telemetry
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	DefaultDecisionWait = 10 * time.Second
+	// DefaultNumTraces matches the default of the collector's tail_sampling
+	// processor.
+	DefaultNumTraces = 50000
+)
+
+// Policy decides whether a trace is sampled from the spans buffered for it.
+type Policy func(spans []sdktrace.ReadOnlySpan) bool
+
+// LatencyPolicy samples traces whose duration, from the earliest span start
+// to the latest span end, is at least threshold.
+func LatencyPolicy(threshold time.Duration) Policy {
+	return func(spans []sdktrace.ReadOnlySpan) bool {
+		var start, end time.Time
+		for _, s := range spans {
+			if start.IsZero() || s.StartTime().Before(start) {
+				start = s.StartTime()
+			}
+			if s.EndTime().After(end) {
+				end = s.EndTime()
+			}
+		}
+		return end.Sub(start) >= threshold
+	}
+}
+
+// ErrorPolicy samples traces containing a span with an error status.
+func ErrorPolicy() Policy {
+	return func(spans []sdktrace.ReadOnlySpan) bool {
+		for _, s := range spans {
+			if s.Status().Code == codes.Error {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// AttributePolicy samples traces containing a span with the attribute key set
+// to one of values.
+func AttributePolicy(key attribute.Key, values ...string) Policy {
+	return func(spans []sdktrace.ReadOnlySpan) bool {
+		for _, s := range spans {
+			for _, attr := range s.Attributes() {
+				if attr.Key != key {
+					continue
+				}
+				for _, v := range values {
+					if attr.Value.Emit() == v {
+						return true
+					}
+				}
+			}
+		}
+		return false
+	}
+}
+
+type TailSamplingConfig struct {
+	// DecisionWait is how long spans are buffered after the first span of a
+	// trace ends. Defaults to DefaultDecisionWait.
+	DecisionWait time.Duration
+	// NumTraces is the number of traces kept in memory, the oldest trace is
+	// dropped when it is exceeded. Defaults to DefaultNumTraces.
+	NumTraces int
+	// Policies are evaluated in order, a trace is sampled as soon as one of
+	// them matches.
+	Policies []Policy
+}
+
+type pendingTrace struct {
+	spans []sdktrace.ReadOnlySpan
+	timer *time.Timer
+	elem  *list.Element
+}
+
+// TailSampler is a span processor that buffers the spans of a trace for the
+// decision window and forwards them to the next processor only when one of
+// the policies samples the trace. Like the collector's tail_sampling
+// processor, spans ending after the decision follow that decision.
+//
+// Only the spans ended in this process are seen, so policies are evaluated
+// on the local part of a distributed trace.
+type TailSampler struct {
+	next sdktrace.SpanProcessor
+	cfg  TailSamplingConfig
+
+	mu           sync.Mutex
+	pending      map[trace.TraceID]*pendingTrace
+	pendingOrder *list.List
+	decided      map[trace.TraceID]bool
+	decidedOrder *list.List
+}
+
+var _ sdktrace.SpanProcessor = (*TailSampler)(nil)
+
+func NewTailSampler(next sdktrace.SpanProcessor, cfg TailSamplingConfig) *TailSampler {
+	if cfg.DecisionWait <= 0 {
+		cfg.DecisionWait = DefaultDecisionWait
+	}
+	if cfg.NumTraces <= 0 {
+		cfg.NumTraces = DefaultNumTraces
+	}
+
+	return &TailSampler{
+		next:         next,
+		cfg:          cfg,
+		pending:      make(map[trace.TraceID]*pendingTrace),
+		pendingOrder: list.New(),
+		decided:      make(map[trace.TraceID]bool),
+		decidedOrder: list.New(),
+	}
+}
+
+func (ts *TailSampler) OnStart(parent context.Context, s sdktrace.ReadWriteSpan) {
+	ts.next.OnStart(parent, s)
+}
+
+func (ts *TailSampler) OnEnd(s sdktrace.ReadOnlySpan) {
+	if !s.SpanContext().IsSampled() {
+		return
+	}
+
+	id := s.SpanContext().TraceID()
+
+	ts.mu.Lock()
+	if sampled, ok := ts.decided[id]; ok {
+		ts.mu.Unlock()
+		if sampled {
+			ts.next.OnEnd(s)
+		}
+		return
+	}
+
+	pt, ok := ts.pending[id]
+	if !ok {
+		pt = &pendingTrace{elem: ts.pendingOrder.PushBack(id)}
+		pt.timer = time.AfterFunc(ts.cfg.DecisionWait, func() { ts.decide(id) })
+		ts.pending[id] = pt
+
+		if ts.pendingOrder.Len() > ts.cfg.NumTraces {
+			oldest := ts.pendingOrder.Front().Value.(trace.TraceID)
+			ts.pending[oldest].timer.Stop()
+			ts.remove(oldest)
+		}
+	}
+	pt.spans = append(pt.spans, s)
+	ts.mu.Unlock()
+}
+
+func (ts *TailSampler) decide(id trace.TraceID) {
+	ts.mu.Lock()
+	pt, ok := ts.pending[id]
+	if !ok {
+		ts.mu.Unlock()
+		return
+	}
+	ts.remove(id)
+
+	sampled := ts.evaluate(pt.spans)
+	ts.decided[id] = sampled
+	ts.decidedOrder.PushBack(id)
+	if ts.decidedOrder.Len() > ts.cfg.NumTraces {
+		delete(ts.decided, ts.decidedOrder.Remove(ts.decidedOrder.Front()).(trace.TraceID))
+	}
+	ts.mu.Unlock()
+
+	if !sampled {
+		return
+	}
+	for _, s := range pt.spans {
+		ts.next.OnEnd(s)
+	}
+}
+
+func (ts *TailSampler) evaluate(spans []sdktrace.ReadOnlySpan) bool {
+	for _, policy := range ts.cfg.Policies {
+		if policy(spans) {
+			return true
+		}
+	}
+	return false
+}
+
+// remove must be called with ts.mu held.
+func (ts *TailSampler) remove(id trace.TraceID) {
+	ts.pendingOrder.Remove(ts.pending[id].elem)
+	delete(ts.pending, id)
+}
+
+// flush decides all pending traces without waiting for their decision window.
+func (ts *TailSampler) flush() {
+	ts.mu.Lock()
+	ids := make([]trace.TraceID, 0, len(ts.pending))
+	for id, pt := range ts.pending {
+		pt.timer.Stop()
+		ids = append(ids, id)
+	}
+	ts.mu.Unlock()
+
+	for _, id := range ids {
+		ts.decide(id)
+	}
+}
+
+func (ts *TailSampler) ForceFlush(ctx context.Context) error {
+	ts.flush()
+	return ts.next.ForceFlush(ctx)
+}
+
+func (ts *TailSampler) Shutdown(ctx context.Context) error {
+	ts.flush()
+	return ts.next.Shutdown(ctx)
+}
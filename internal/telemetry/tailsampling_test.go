@@ -0,0 +1,197 @@
+package telemetry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func stubSpan(traceID, spanID byte, start time.Time, d time.Duration) tracetest.SpanStub {
+	return tracetest.SpanStub{
+		Name: "span",
+		SpanContext: trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID:    trace.TraceID{traceID},
+			SpanID:     trace.SpanID{spanID},
+			TraceFlags: trace.FlagsSampled,
+		}),
+		StartTime: start,
+		EndTime:   start.Add(d),
+	}
+}
+
+func snapshots(stubs ...tracetest.SpanStub) []sdktrace.ReadOnlySpan {
+	spans := make([]sdktrace.ReadOnlySpan, len(stubs))
+	for i, s := range stubs {
+		spans[i] = s.Snapshot()
+	}
+	return spans
+}
+
+func TestPolicies(t *testing.T) {
+	now := time.Now()
+
+	failed := stubSpan(1, 2, now, time.Millisecond)
+	failed.Status = sdktrace.Status{Code: codes.Error, Description: "boom"}
+
+	tagged := stubSpan(1, 3, now, time.Millisecond)
+	tagged.Attributes = []attribute.KeyValue{attribute.String("tenant", "b")}
+
+	tests := []struct {
+		name   string
+		policy Policy
+		spans  []sdktrace.ReadOnlySpan
+		want   bool
+	}{
+		{name: "latency below", policy: LatencyPolicy(time.Second), spans: snapshots(stubSpan(1, 1, now, 500*time.Millisecond)), want: false},
+		{name: "latency at", policy: LatencyPolicy(time.Second), spans: snapshots(stubSpan(1, 1, now, time.Second)), want: true},
+		{
+			name:   "latency across spans",
+			policy: LatencyPolicy(time.Second),
+			spans:  snapshots(stubSpan(1, 1, now, 100*time.Millisecond), stubSpan(1, 2, now.Add(900*time.Millisecond), 200*time.Millisecond)),
+			want:   true,
+		},
+		{name: "error", policy: ErrorPolicy(), spans: snapshots(stubSpan(1, 1, now, 0), failed), want: true},
+		{name: "no error", policy: ErrorPolicy(), spans: snapshots(stubSpan(1, 1, now, 0)), want: false},
+		{name: "attribute", policy: AttributePolicy("tenant", "a", "b"), spans: snapshots(stubSpan(1, 1, now, 0), tagged), want: true},
+		{name: "attribute value", policy: AttributePolicy("tenant", "a"), spans: snapshots(tagged), want: false},
+		{name: "attribute key", policy: AttributePolicy("region", "b"), spans: snapshots(tagged), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy(tt.spans); got != tt.want {
+				t.Errorf("got %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func newTestTailSampler(cfg TailSamplingConfig) (*TailSampler, *tracetest.InMemoryExporter) {
+	exporter := tracetest.NewInMemoryExporter()
+	return NewTailSampler(sdktrace.NewSimpleSpanProcessor(exporter), cfg), exporter
+}
+
+func TestTailSamplerDecide(t *testing.T) {
+	ts, exporter := newTestTailSampler(TailSamplingConfig{
+		DecisionWait: time.Hour,
+		Policies:     []Policy{LatencyPolicy(time.Second)},
+	})
+	now := time.Now()
+
+	ts.OnEnd(stubSpan(1, 1, now, 100*time.Millisecond).Snapshot())
+	ts.OnEnd(stubSpan(1, 2, now, 2*time.Second).Snapshot())
+	ts.OnEnd(stubSpan(2, 1, now, 100*time.Millisecond).Snapshot())
+
+	if got := len(exporter.GetSpans()); got != 0 {
+		t.Fatalf("exported %d spans before the decision, want 0", got)
+	}
+
+	if err := ts.ForceFlush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("exported %d spans, want the 2 spans of the slow trace", len(spans))
+	}
+	for _, s := range spans {
+		if s.SpanContext.TraceID() != (trace.TraceID{1}) {
+			t.Errorf("exported a span of trace %s", s.SpanContext.TraceID())
+		}
+	}
+}
+
+func TestTailSamplerDecisionWait(t *testing.T) {
+	ts, exporter := newTestTailSampler(TailSamplingConfig{
+		DecisionWait: 10 * time.Millisecond,
+		Policies:     []Policy{ErrorPolicy()},
+	})
+
+	failed := stubSpan(1, 1, time.Now(), 0)
+	failed.Status = sdktrace.Status{Code: codes.Error}
+	ts.OnEnd(failed.Snapshot())
+
+	deadline := time.Now().Add(time.Second)
+	for len(exporter.GetSpans()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("trace not decided after the decision wait")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestTailSamplerLateSpans(t *testing.T) {
+	ts, exporter := newTestTailSampler(TailSamplingConfig{
+		DecisionWait: time.Hour,
+		Policies:     []Policy{LatencyPolicy(time.Second)},
+	})
+	now := time.Now()
+
+	ts.OnEnd(stubSpan(1, 1, now, 2*time.Second).Snapshot())
+	ts.OnEnd(stubSpan(2, 1, now, 0).Snapshot())
+	if err := ts.ForceFlush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	exporter.Reset()
+
+	// Late spans follow the decision of their trace, even when they would
+	// change the outcome of the policies.
+	ts.OnEnd(stubSpan(1, 2, now, 0).Snapshot())
+	ts.OnEnd(stubSpan(2, 2, now, 2*time.Second).Snapshot())
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("exported %d late spans, want 1", len(spans))
+	}
+	if got := spans[0].SpanContext.SpanID(); got != (trace.SpanID{2}) || spans[0].SpanContext.TraceID() != (trace.TraceID{1}) {
+		t.Errorf("exported late span %s of trace %s, want the span of the sampled trace", got, spans[0].SpanContext.TraceID())
+	}
+}
+
+func TestTailSamplerEvictsOldestTrace(t *testing.T) {
+	ts, exporter := newTestTailSampler(TailSamplingConfig{
+		DecisionWait: time.Hour,
+		NumTraces:    2,
+		Policies:     []Policy{func([]sdktrace.ReadOnlySpan) bool { return true }},
+	})
+	now := time.Now()
+
+	for id := byte(1); id <= 3; id++ {
+		ts.OnEnd(stubSpan(id, 1, now, 0).Snapshot())
+	}
+	if err := ts.ForceFlush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	got := map[trace.TraceID]bool{}
+	for _, s := range exporter.GetSpans() {
+		got[s.SpanContext.TraceID()] = true
+	}
+	if len(got) != 2 || got[trace.TraceID{1}] {
+		t.Errorf("exported traces %v, want the 2 newest", got)
+	}
+}
+
+func TestTailSamplerSkipsUnsampledSpans(t *testing.T) {
+	ts, exporter := newTestTailSampler(TailSamplingConfig{
+		DecisionWait: time.Hour,
+		Policies:     []Policy{func([]sdktrace.ReadOnlySpan) bool { return true }},
+	})
+
+	s := stubSpan(1, 1, time.Now(), 0)
+	s.SpanContext = s.SpanContext.WithTraceFlags(0)
+	ts.OnEnd(s.Snapshot())
+	if err := ts.ForceFlush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := len(exporter.GetSpans()); got != 0 {
+		t.Errorf("exported %d unsampled spans, want 0", got)
+	}
+}
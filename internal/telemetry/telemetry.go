@@ -0,0 +1,297 @@
+package telemetry
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/jaeger"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/exporters/zipkin"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+)
+
+type Exporter string
+
+const (
+	ExporterJaeger   Exporter = "jaeger"
+	ExporterZipkin   Exporter = "zipkin"
+	ExporterOTLPGRPC Exporter = "otlpgrpc"
+	ExporterOTLPHTTP Exporter = "otlphttp"
+	ExporterStdout   Exporter = "stdout"
+	ExporterNone     Exporter = "none"
+)
+
+var exporters = []Exporter{ExporterJaeger, ExporterZipkin, ExporterOTLPGRPC, ExporterOTLPHTTP, ExporterStdout, ExporterNone}
+
+func (e *Exporter) String() string { return string(*e) }
+
+// Set also accepts the OTEL_TRACES_EXPORTER values of the specification:
+// otlp, which selects otlphttp when OTEL_EXPORTER_OTLP_PROTOCOL is
+// http/protobuf and otlpgrpc otherwise, and console and logging, which select
+// stdout.
+func (e *Exporter) Set(s string) error {
+	switch s {
+	case "otlp":
+		*e = otlpExporterFromEnv()
+		return nil
+	case "console", "logging":
+		*e = ExporterStdout
+		return nil
+	}
+
+	for _, exp := range exporters {
+		if Exporter(s) == exp {
+			*e = exp
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown exporter %q, want one of %v", s, exporters)
+}
+
+func otlpExporterFromEnv() Exporter {
+	protocol := os.Getenv(otlpTracesProtocolEnv)
+	if protocol == "" {
+		protocol = os.Getenv(otlpProtocolEnv)
+	}
+	if protocol == "http/protobuf" {
+		return ExporterOTLPHTTP
+	}
+	return ExporterOTLPGRPC
+}
+
+// exporterFlag sets the exporter of cfg, discarding an invalid
+// OTEL_TRACES_EXPORTER value it overrides.
+type exporterFlag struct {
+	cfg *Config
+}
+
+func (f exporterFlag) String() string {
+	if f.cfg == nil {
+		return ""
+	}
+	return f.cfg.Exporter.String()
+}
+
+func (f exporterFlag) Set(s string) error {
+	if err := f.cfg.Exporter.Set(s); err != nil {
+		return err
+	}
+	f.cfg.exporterErr = nil
+	return nil
+}
+
+const (
+	DefaultJaegerEndpoint   = "http://localhost:14260/api/traces"
+	DefaultZipkinEndpoint   = "http://localhost:9411/api/v2/spans"
+	DefaultOTLPGRPCEndpoint = "localhost:55690"
+	DefaultOTLPHTTPEndpoint = "localhost:55681"
+)
+
+const (
+	exporterEnv           = "OTEL_TRACES_EXPORTER"
+	otlpEndpointEnv       = "OTEL_EXPORTER_OTLP_ENDPOINT"
+	otlpTracesEndpointEnv = "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"
+	otlpProtocolEnv       = "OTEL_EXPORTER_OTLP_PROTOCOL"
+	otlpTracesProtocolEnv = "OTEL_EXPORTER_OTLP_TRACES_PROTOCOL"
+)
+
+type Config struct {
+	// ServiceName is the default service.name, OTEL_SERVICE_NAME takes
+	// precedence over it.
+	ServiceName string
+	Exporter    Exporter
+	// Endpoint overrides the exporter's default endpoint when set.
+	Endpoint string
+	// Sampler defaults to the one selected by the -sampler flag or
+	// OTEL_TRACES_SAMPLER, see BaseSampler.
+	Sampler sdktrace.Sampler
+	// Attributes are merged with, and overridden by,
+	// OTEL_RESOURCE_ATTRIBUTES.
+	Attributes []attribute.KeyValue
+	// TailSampling enables the in-process TailSampler in front of the
+	// exporter when set.
+	TailSampling *TailSamplingConfig
+
+	// exporterErr is the error of an invalid OTEL_TRACES_EXPORTER value,
+	// returned by NewTracerProvider unless the -exporter flag overrides it.
+	exporterErr error
+
+	samplerName      string
+	samplerArg       string
+	tailLatency      time.Duration
+	tailDecisionWait time.Duration
+	tailNumTraces    int
+}
+
+// RegisterFlags binds the exporter, sampler and tail sampling flags to fs and
+// returns the Config they populate. The exporter defaults to the value of
+// OTEL_TRACES_EXPORTER, falling back to jaeger.
+func RegisterFlags(fs *flag.FlagSet) *Config {
+	cfg := &Config{Exporter: ExporterJaeger}
+	if v := os.Getenv(exporterEnv); v != "" {
+		if err := cfg.Exporter.Set(v); err != nil {
+			cfg.exporterErr = fmt.Errorf("%s: %w", exporterEnv, err)
+		}
+	}
+
+	fs.Var(exporterFlag{cfg: cfg}, "exporter", fmt.Sprintf("trace exporter, one of %v", exporters))
+	fs.StringVar(&cfg.Endpoint, "exporterEndpoint", "", "trace exporter endpoint, defaults depend on the exporter")
+	fs.StringVar(&cfg.samplerName, "sampler", "", "trace sampler, one of the OTEL_TRACES_SAMPLER values, defaults to always_on")
+	fs.StringVar(&cfg.samplerArg, "samplerArg", "", "sampling ratio for the traceidratio samplers")
+	fs.DurationVar(&cfg.tailLatency, "tailSamplingLatency", 0, "tail sample traces at least this long in process, disabled when 0")
+	fs.DurationVar(&cfg.tailDecisionWait, "tailSamplingDecisionWait", DefaultDecisionWait, "how long to buffer a trace before the tail sampling decision")
+	fs.IntVar(&cfg.tailNumTraces, "tailSamplingNumTraces", DefaultNumTraces, "how many traces to buffer for the tail sampling decision, the oldest is dropped beyond it")
+	return cfg
+}
+
+type ShutdownFunc func(context.Context) error
+
+// Setup builds a TracerProvider from cfg and installs it, together with the
+// W3C trace context and baggage propagators, as the global provider.
+func Setup(ctx context.Context, cfg Config) (ShutdownFunc, error) {
+	tp, err := NewTracerProvider(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	return tp.Shutdown, nil
+}
+
+func NewTracerProvider(ctx context.Context, cfg Config) (*sdktrace.TracerProvider, error) {
+	if cfg.exporterErr != nil {
+		return nil, cfg.exporterErr
+	}
+
+	sampler := cfg.Sampler
+	if sampler == nil {
+		s, err := cfg.BaseSampler()
+		if err != nil {
+			return nil, err
+		}
+		sampler = s
+	}
+
+	res, err := NewResource(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []sdktrace.TracerProviderOption{
+		sdktrace.WithSampler(sampler),
+		sdktrace.WithResource(res),
+	}
+
+	if cfg.Exporter != ExporterNone {
+		exporter, err := NewExporter(ctx, cfg)
+		if err != nil {
+			return nil, err
+		}
+		bsp := sdktrace.NewBatchSpanProcessor(exporter)
+
+		var sp sdktrace.SpanProcessor = bsp
+		if ts := cfg.tailSamplingConfig(); ts != nil {
+			sp = NewTailSampler(bsp, *ts)
+		}
+
+		opts = append(opts,
+			sdktrace.WithSpanProcessor(sp),
+			sdktrace.WithSpanProcessor(errorSpanProcessor{next: bsp}),
+		)
+	}
+
+	return sdktrace.NewTracerProvider(opts...), nil
+}
+
+// BaseSampler returns the sampler selected by the -sampler flags, falling back
+// to OTEL_TRACES_SAMPLER and then to sdktrace.AlwaysSample.
+func (c Config) BaseSampler() (sdktrace.Sampler, error) {
+	if c.samplerName != "" {
+		return NewSampler(c.samplerName, c.samplerArg)
+	}
+
+	sampler, err := samplerFromEnv()
+	if err != nil || sampler != nil {
+		return sampler, err
+	}
+
+	return sdktrace.AlwaysSample(), nil
+}
+
+func (c Config) tailSamplingConfig() *TailSamplingConfig {
+	if c.TailSampling != nil || c.tailLatency <= 0 {
+		return c.TailSampling
+	}
+
+	return &TailSamplingConfig{
+		DecisionWait: c.tailDecisionWait,
+		NumTraces:    c.tailNumTraces,
+		Policies:     []Policy{LatencyPolicy(c.tailLatency)},
+	}
+}
+
+func NewResource(ctx context.Context, cfg Config) (*resource.Resource, error) {
+	attrs := append([]attribute.KeyValue{semconv.ServiceNameKey.String(cfg.ServiceName)}, cfg.Attributes...)
+	return resource.New(ctx,
+		resource.WithSchemaURL(semconv.SchemaURL),
+		resource.WithAttributes(attrs...),
+		resource.WithFromEnv(),
+	)
+}
+
+func NewExporter(ctx context.Context, cfg Config) (sdktrace.SpanExporter, error) {
+	switch cfg.Exporter {
+	case ExporterJaeger:
+		return jaeger.New(jaeger.WithCollectorEndpoint(jaeger.WithEndpoint(endpointOr(cfg.Endpoint, DefaultJaegerEndpoint))))
+	case ExporterZipkin:
+		return zipkin.New(endpointOr(cfg.Endpoint, DefaultZipkinEndpoint))
+	case ExporterOTLPGRPC:
+		var opts []otlptracegrpc.Option
+		if cfg.Endpoint != "" || !otlpEndpointFromEnv() {
+			opts = append(opts,
+				otlptracegrpc.WithInsecure(),
+				otlptracegrpc.WithEndpoint(endpointOr(cfg.Endpoint, DefaultOTLPGRPCEndpoint)),
+			)
+		}
+		return otlptracegrpc.New(ctx, opts...)
+	case ExporterOTLPHTTP:
+		var opts []otlptracehttp.Option
+		if cfg.Endpoint != "" || !otlpEndpointFromEnv() {
+			opts = append(opts,
+				otlptracehttp.WithInsecure(),
+				otlptracehttp.WithEndpoint(endpointOr(cfg.Endpoint, DefaultOTLPHTTPEndpoint)),
+			)
+		}
+		return otlptracehttp.New(ctx, opts...)
+	case ExporterStdout:
+		return stdouttrace.New(stdouttrace.WithPrettyPrint())
+	default:
+		return nil, fmt.Errorf("unknown exporter %q", cfg.Exporter)
+	}
+}
+
+// otlpEndpointFromEnv reports whether the OTLP endpoint is configured through
+// the environment, in which case the exporters read it, and whether to use
+// TLS, themselves.
+func otlpEndpointFromEnv() bool {
+	return os.Getenv(otlpEndpointEnv) != "" || os.Getenv(otlpTracesEndpointEnv) != ""
+}
+
+func endpointOr(endpoint, def string) string {
+	if endpoint == "" {
+		return def
+	}
+	return endpoint
+}
@@ -0,0 +1,80 @@
+package telemetry
+
+import (
+	"context"
+	"flag"
+	"testing"
+)
+
+func TestExporterSet(t *testing.T) {
+	tests := []struct {
+		value    string
+		protocol string
+		want     Exporter
+	}{
+		{value: "jaeger", want: ExporterJaeger},
+		{value: "otlpgrpc", want: ExporterOTLPGRPC},
+		{value: "otlp", want: ExporterOTLPGRPC},
+		{value: "otlp", protocol: "grpc", want: ExporterOTLPGRPC},
+		{value: "otlp", protocol: "http/protobuf", want: ExporterOTLPHTTP},
+		{value: "console", want: ExporterStdout},
+		{value: "logging", want: ExporterStdout},
+		{value: "none", want: ExporterNone},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value+"/"+tt.protocol, func(t *testing.T) {
+			t.Setenv(otlpProtocolEnv, tt.protocol)
+
+			var e Exporter
+			if err := e.Set(tt.value); err != nil {
+				t.Fatal(err)
+			}
+			if e != tt.want {
+				t.Errorf("got %q, want %q", e, tt.want)
+			}
+		})
+	}
+
+	var e Exporter
+	if err := e.Set("otlpx"); err == nil {
+		t.Error("expected an error for an unknown exporter")
+	}
+}
+
+func TestRegisterFlagsExporterEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     string
+		args    []string
+		want    Exporter
+		wantErr bool
+	}{
+		{name: "default", want: ExporterJaeger},
+		{name: "env", env: "console", want: ExporterStdout},
+		{name: "invalid env", env: "bogus", wantErr: true},
+		{name: "flag overrides invalid env", env: "bogus", args: []string{"-exporter", "none"}, want: ExporterNone},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(exporterEnv, tt.env)
+
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			cfg := RegisterFlags(fs)
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+			if !tt.wantErr && cfg.Exporter != tt.want {
+				t.Errorf("got exporter %q, want %q", cfg.Exporter, tt.want)
+			}
+
+			// The exporter is forced to none so that no connection is made.
+			cfg.Exporter = ExporterNone
+			_, err := NewTracerProvider(context.Background(), *cfg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("got error %v, want error %t", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package user
+
+import (
+	"errors"
+	"toy/internal/grpcerr"
+
+	grpccodes "google.golang.org/grpc/codes"
+)
+
+const errorDomain = "user.toy"
+
+var (
+	ErrNotFound         = errors.New("user not found")
+	ErrAlreadyExists    = errors.New("user already exists")
+	ErrInvalidUsername  = errors.New("invalid username")
+	ErrInvalidPassword  = errors.New("invalid password")
+	ErrInvalidId        = errors.New("invalid user id")
+	ErrInvalidPageToken = errors.New("invalid page token")
+)
+
+var errorMappings = []grpcerr.Mapping{
+	{Err: ErrNotFound, Code: grpccodes.NotFound, Reason: "USER_NOT_FOUND"},
+	{Err: ErrAlreadyExists, Code: grpccodes.AlreadyExists, Reason: "USER_ALREADY_EXISTS"},
+	{Err: ErrInvalidUsername, Code: grpccodes.InvalidArgument, Reason: "INVALID_USERNAME"},
+	{Err: ErrInvalidPassword, Code: grpccodes.InvalidArgument, Reason: "INVALID_PASSWORD"},
+	{Err: ErrInvalidId, Code: grpccodes.InvalidArgument, Reason: "INVALID_USER_ID"},
+	{Err: ErrInvalidPageToken, Code: grpccodes.InvalidArgument, Reason: "INVALID_PAGE_TOKEN"},
+}
+
+func toStatus(err error) error {
+	return grpcerr.Status(err, errorDomain, errorMappings)
+}
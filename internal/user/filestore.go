@@ -0,0 +1,174 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// FileStore keeps the users in memory and persists them as JSON to a file on
+// every change, so they survive a restart.
+type FileStore struct {
+	path   string
+	users  userIndex
+	mu     sync.RWMutex
+	tracer trace.Tracer
+}
+
+var _ Store = (*FileStore)(nil)
+
+// NewFileStore loads the users from path, which is created on the first
+// change if it does not exist.
+func NewFileStore(path string) (*FileStore, error) {
+	fs := &FileStore{
+		path:   path,
+		users:  newUserIndex(),
+		tracer: otel.Tracer("user-store"),
+	}
+
+	b, err := ioutil.ReadFile(path)
+	switch {
+	case os.IsNotExist(err):
+		return fs, nil
+	case err != nil:
+		return nil, errors.Wrap(err, "failed to read user store")
+	}
+
+	var users map[string]User
+	if err := json.Unmarshal(b, &users); err != nil {
+		return nil, errors.Wrapf(err, "failed to decode user store %s", path)
+	}
+	for _, usr := range users {
+		if err := fs.users.add(usr); err != nil {
+			return nil, errors.Wrapf(err, "failed to load user %s from %s", usr.Id, path)
+		}
+	}
+
+	return fs, nil
+}
+
+func (fs *FileStore) newSpan(ctx context.Context, op Operation) (context.Context, trace.Span) {
+	ctx, span := fs.tracer.Start(ctx, string(op))
+	span.SetAttributes(storeBackendKey.String("file"), attribute.String("store.path", fs.path))
+	return ctx, span
+}
+
+func (fs *FileStore) Add(ctx context.Context, user User) error {
+	_, span := fs.newSpan(ctx, OperationAdd)
+	span.SetAttributes(attribute.String("user_id", user.Id), attribute.String("username", user.Username))
+	defer span.End()
+
+	return spanError(span, fs.mutate(func(users userIndex) error {
+		return users.add(user)
+	}))
+}
+
+func (fs *FileStore) GetByName(ctx context.Context, username string) (User, error) {
+	_, span := fs.newSpan(ctx, OperationGetByName)
+	span.SetAttributes(attribute.String("username", username))
+	defer span.End()
+
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	usr, err := fs.users.getByName(username)
+	span.SetAttributes(attribute.Bool("found", err == nil))
+	return usr, spanError(span, err)
+}
+
+func (fs *FileStore) GetById(ctx context.Context, id string) (User, error) {
+	_, span := fs.newSpan(ctx, OperationGetById)
+	span.SetAttributes(attribute.String("user_id", id))
+	defer span.End()
+
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	usr, err := fs.users.getById(id)
+	span.SetAttributes(attribute.Bool("found", err == nil))
+	return usr, spanError(span, err)
+}
+
+func (fs *FileStore) Update(ctx context.Context, user User) error {
+	_, span := fs.newSpan(ctx, OperationUpdate)
+	span.SetAttributes(attribute.String("user_id", user.Id), attribute.String("username", user.Username))
+	defer span.End()
+
+	return spanError(span, fs.mutate(func(users userIndex) error {
+		return users.update(user)
+	}))
+}
+
+func (fs *FileStore) Delete(ctx context.Context, id string) error {
+	_, span := fs.newSpan(ctx, OperationDelete)
+	span.SetAttributes(attribute.String("user_id", id))
+	defer span.End()
+
+	return spanError(span, fs.mutate(func(users userIndex) error {
+		return users.delete(id)
+	}))
+}
+
+func (fs *FileStore) List(ctx context.Context, afterId string, limit int) ([]User, error) {
+	_, span := fs.newSpan(ctx, OperationList)
+	span.SetAttributes(attribute.String("after_id", afterId), attribute.Int("limit", limit))
+	defer span.End()
+
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	return fs.users.list(afterId, limit), nil
+}
+
+// mutate applies fn to a copy of the users and saves it, the users are only
+// replaced by the copy when both succeed.
+func (fs *FileStore) mutate(fn func(users userIndex) error) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	users := fs.users.clone()
+	if err := fn(users); err != nil {
+		return err
+	}
+	if err := fs.save(users); err != nil {
+		return err
+	}
+
+	fs.users = users
+	return nil
+}
+
+// save writes the users to a temporary file and renames it over path, so a
+// crash never leaves a partially written store behind.
+func (fs *FileStore) save(users userIndex) error {
+	byName := make(map[string]User, len(users.byId))
+	for _, usr := range users.byId {
+		byName[usr.Username] = usr
+	}
+
+	b, err := json.MarshalIndent(byName, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to encode user store")
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(fs.path), filepath.Base(fs.path)+".*")
+	if err != nil {
+		return errors.Wrap(err, "failed to create user store")
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return errors.Wrap(err, "failed to write user store")
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.Wrap(err, "failed to write user store")
+	}
+
+	return errors.Wrap(os.Rename(tmp.Name(), fs.path), "failed to replace user store")
+}
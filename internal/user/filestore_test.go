@@ -0,0 +1,68 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func newTestFileStore(t *testing.T, path string) *FileStore {
+	t.Helper()
+
+	store, err := NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func TestFileStore(t *testing.T) {
+	testStore(t, newTestFileStore(t, filepath.Join(t.TempDir(), "users.json")))
+}
+
+func TestFileStoreReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.json")
+	ctx := context.Background()
+
+	want := User{Id: "1", Username: "kasutaja", PasswordHash: "hash"}
+	if err := newTestFileStore(t, path).Add(ctx, want); err != nil {
+		t.Fatal(err)
+	}
+
+	// A new store on the same path is what the service sees after a restart.
+	usr, err := newTestFileStore(t, path).GetByName(ctx, "kasutaja")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usr != want {
+		t.Errorf("got %+v, want %+v", usr, want)
+	}
+}
+
+func TestFileStoreFailedSave(t *testing.T) {
+	dir := t.TempDir()
+	store := newTestFileStore(t, filepath.Join(dir, "users.json"))
+	ctx := context.Background()
+
+	if err := store.Add(ctx, User{Id: "1", Username: "kasutaja"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The temporary file cannot be created in a missing directory.
+	store.path = filepath.Join(dir, "missing", "users.json")
+
+	if err := store.Add(ctx, User{Id: "2", Username: "teine"}); err == nil {
+		t.Fatal("expected the add to fail")
+	}
+	if _, err := store.GetByName(ctx, "teine"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("got %v, want the failed add not to be kept", err)
+	}
+
+	if err := store.Delete(ctx, "1"); err == nil {
+		t.Fatal("expected the delete to fail")
+	}
+	if _, err := store.GetById(ctx, "1"); err != nil {
+		t.Errorf("got %v, want the failed delete not to be kept", err)
+	}
+}
@@ -0,0 +1,274 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	"go.opentelemetry.io/otel/trace"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// migrations are applied in order, the version of a migration is its index
+// plus one. Append new migrations, never edit applied ones.
+var migrations = []string{
+	`CREATE TABLE users (
+		id            TEXT PRIMARY KEY,
+		username      TEXT NOT NULL UNIQUE,
+		password_hash TEXT NOT NULL
+	)`,
+}
+
+// SQLStore keeps the users in a SQLite database accessed through
+// database/sql. Every query is traced as a client span.
+type SQLStore struct {
+	db     *sql.DB
+	tracer trace.Tracer
+}
+
+var _ Store = (*SQLStore)(nil)
+
+// OpenSQLite opens the SQLite database at path for a SQLStore. SQLite allows a
+// single writer, so the pool is limited to one connection and the busy
+// timeout covers other processes holding the lock.
+func OpenSQLite(path string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite", path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+	return db, nil
+}
+
+// NewSQLStore migrates db to the latest schema and returns a store using it.
+// db must use a SQLite driver, such as the one opened by OpenSQLite.
+func NewSQLStore(ctx context.Context, db *sql.DB) (*SQLStore, error) {
+	s := &SQLStore{
+		db:     db,
+		tracer: otel.Tracer("user-store"),
+	}
+
+	if err := s.migrate(ctx); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *SQLStore) Add(ctx context.Context, user User) error {
+	const stmt = `INSERT INTO users (id, username, password_hash) VALUES (?, ?, ?)`
+
+	ctx, span := s.newSpan(ctx, "INSERT", "users", stmt)
+	span.SetAttributes(attribute.String("user_id", user.Id), attribute.String("username", user.Username))
+	defer span.End()
+
+	if _, err := s.db.ExecContext(ctx, stmt, user.Id, user.Username, user.PasswordHash); err != nil {
+		return spanError(span, wrapErr(err, "failed to add user"))
+	}
+
+	return nil
+}
+
+func (s *SQLStore) GetByName(ctx context.Context, username string) (User, error) {
+	const stmt = `SELECT id, username, password_hash FROM users WHERE username = ?`
+
+	ctx, span := s.newSpan(ctx, "SELECT", "users", stmt)
+	span.SetAttributes(attribute.String("username", username))
+	defer span.End()
+
+	return s.get(ctx, span, stmt, username)
+}
+
+func (s *SQLStore) GetById(ctx context.Context, id string) (User, error) {
+	const stmt = `SELECT id, username, password_hash FROM users WHERE id = ?`
+
+	ctx, span := s.newSpan(ctx, "SELECT", "users", stmt)
+	span.SetAttributes(attribute.String("user_id", id))
+	defer span.End()
+
+	return s.get(ctx, span, stmt, id)
+}
+
+func (s *SQLStore) get(ctx context.Context, span trace.Span, stmt string, arg string) (User, error) {
+	var usr User
+	err := s.db.QueryRowContext(ctx, stmt, arg).Scan(&usr.Id, &usr.Username, &usr.PasswordHash)
+	span.SetAttributes(attribute.Bool("found", err == nil))
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return User{}, spanError(span, ErrNotFound)
+	case err != nil:
+		return User{}, spanError(span, errors.Wrap(err, "failed to get user"))
+	}
+
+	return usr, nil
+}
+
+func (s *SQLStore) Update(ctx context.Context, user User) error {
+	const stmt = `UPDATE users SET username = ?, password_hash = ? WHERE id = ?`
+
+	ctx, span := s.newSpan(ctx, "UPDATE", "users", stmt)
+	span.SetAttributes(attribute.String("user_id", user.Id), attribute.String("username", user.Username))
+	defer span.End()
+
+	res, err := s.db.ExecContext(ctx, stmt, user.Username, user.PasswordHash, user.Id)
+	if err != nil {
+		return spanError(span, wrapErr(err, "failed to update user"))
+	}
+
+	return spanError(span, affected(res))
+}
+
+func (s *SQLStore) Delete(ctx context.Context, id string) error {
+	const stmt = `DELETE FROM users WHERE id = ?`
+
+	ctx, span := s.newSpan(ctx, "DELETE", "users", stmt)
+	span.SetAttributes(attribute.String("user_id", id))
+	defer span.End()
+
+	res, err := s.db.ExecContext(ctx, stmt, id)
+	if err != nil {
+		return spanError(span, errors.Wrap(err, "failed to delete user"))
+	}
+
+	return spanError(span, affected(res))
+}
+
+func (s *SQLStore) List(ctx context.Context, afterId string, limit int) ([]User, error) {
+	const stmt = `SELECT id, username, password_hash FROM users WHERE id > ? ORDER BY id LIMIT ?`
+
+	ctx, span := s.newSpan(ctx, "SELECT", "users", stmt)
+	span.SetAttributes(attribute.String("after_id", afterId), attribute.Int("limit", limit))
+	defer span.End()
+
+	rows, err := s.db.QueryContext(ctx, stmt, afterId, limit)
+	if err != nil {
+		return nil, spanError(span, errors.Wrap(err, "failed to list users"))
+	}
+	defer rows.Close()
+
+	var users []User
+	for rows.Next() {
+		var usr User
+		if err := rows.Scan(&usr.Id, &usr.Username, &usr.PasswordHash); err != nil {
+			return nil, spanError(span, errors.Wrap(err, "failed to list users"))
+		}
+		users = append(users, usr)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, spanError(span, errors.Wrap(err, "failed to list users"))
+	}
+
+	return users, nil
+}
+
+// affected returns ErrNotFound when the statement did not change any row.
+func affected(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// wrapErr translates unique constraint violations to ErrAlreadyExists and
+// wraps other errors with msg.
+func wrapErr(err error, msg string) error {
+	var serr *sqlite.Error
+	if errors.As(err, &serr) {
+		switch serr.Code() {
+		case sqlite3.SQLITE_CONSTRAINT_UNIQUE, sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY:
+			return ErrAlreadyExists
+		}
+	}
+	return errors.Wrap(err, msg)
+}
+
+// migrate applies the migrations newer than the version recorded in
+// schema_migrations, each in its own transaction.
+func (s *SQLStore) migrate(ctx context.Context) error {
+	const create = `CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER PRIMARY KEY)`
+	if err := s.exec(ctx, s.db, "CREATE", "schema_migrations", create); err != nil {
+		return errors.Wrap(err, "failed to create schema_migrations")
+	}
+
+	version, err := s.schemaVersion(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to read schema version")
+	}
+
+	for i := version; i < len(migrations); i++ {
+		if err := s.applyMigration(ctx, i+1, migrations[i]); err != nil {
+			return errors.Wrapf(err, "failed to apply migration %d", i+1)
+		}
+	}
+
+	return nil
+}
+
+func (s *SQLStore) schemaVersion(ctx context.Context) (int, error) {
+	const stmt = `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`
+
+	ctx, span := s.newSpan(ctx, "SELECT", "schema_migrations", stmt)
+	defer span.End()
+
+	var version int
+	if err := s.db.QueryRowContext(ctx, stmt).Scan(&version); err != nil {
+		return 0, spanError(span, err)
+	}
+	return version, nil
+}
+
+func (s *SQLStore) applyMigration(ctx context.Context, version int, stmt string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := s.exec(ctx, tx, "MIGRATE", "", stmt); err != nil {
+		return err
+	}
+	if err := s.exec(ctx, tx, "INSERT", "schema_migrations", `INSERT INTO schema_migrations (version) VALUES (?)`, version); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+func (s *SQLStore) exec(ctx context.Context, e execer, op, table, stmt string, args ...interface{}) error {
+	ctx, span := s.newSpan(ctx, op, table, stmt)
+	defer span.End()
+
+	if _, err := e.ExecContext(ctx, stmt, args...); err != nil {
+		return spanError(span, err)
+	}
+	return nil
+}
+
+// newSpan starts a client span for a query, named after the operation and
+// table as the database semantic conventions recommend.
+func (s *SQLStore) newSpan(ctx context.Context, op, table, stmt string) (context.Context, trace.Span) {
+	name := op
+	attrs := []attribute.KeyValue{
+		semconv.DBSystemSqlite,
+		semconv.DBStatementKey.String(stmt),
+		semconv.DBOperationKey.String(op),
+	}
+	if table != "" {
+		name += " " + table
+		attrs = append(attrs, semconv.DBSQLTableKey.String(table))
+	}
+
+	return s.tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
@@ -0,0 +1,129 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func newTestSQLStore(t *testing.T, path string) *SQLStore {
+	t.Helper()
+
+	db, err := OpenSQLite(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	store, err := NewSQLStore(context.Background(), db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func TestSQLStore(t *testing.T) {
+	testStore(t, newTestSQLStore(t, filepath.Join(t.TempDir(), "users.db")))
+}
+
+func TestSQLStoreMigrations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.db")
+	ctx := context.Background()
+
+	store := newTestSQLStore(t, path)
+	if err := store.Add(ctx, User{Id: "1", Username: "kasutaja"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Reopening the database must not apply the migrations again.
+	store = newTestSQLStore(t, path)
+	version, err := store.schemaVersion(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != len(migrations) {
+		t.Errorf("got schema version %d, want %d", version, len(migrations))
+	}
+	if _, err := store.GetById(ctx, "1"); err != nil {
+		t.Errorf("got %v, want the user added before reopening", err)
+	}
+}
+
+func TestSQLStoreConcurrentAdds(t *testing.T) {
+	store := newTestSQLStore(t, filepath.Join(t.TempDir(), "users.db"))
+	ctx := context.Background()
+
+	const n = 50
+	errs := make(chan error, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- store.Add(ctx, User{Id: fmt.Sprint(i), Username: fmt.Sprint("user", i)})
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	users, err := store.List(ctx, "", n+1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != n {
+		t.Errorf("got %d users, want %d", len(users), n)
+	}
+}
+
+func TestSQLStoreSpans(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(tp)
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	store := newTestSQLStore(t, filepath.Join(t.TempDir(), "users.db"))
+	exporter.Reset()
+
+	if _, err := store.GetByName(context.Background(), "missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("got %v, want ErrNotFound", err)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans, want 1", len(spans))
+	}
+	span := spans[0]
+	if span.Name != "SELECT users" || span.SpanKind != trace.SpanKindClient {
+		t.Errorf("got %s span %q, want client span \"SELECT users\"", span.SpanKind, span.Name)
+	}
+
+	attrs := attribute.NewSet(span.Attributes...)
+	for _, want := range []attribute.KeyValue{
+		semconv.DBSystemSqlite,
+		semconv.DBOperationKey.String("SELECT"),
+		semconv.DBSQLTableKey.String("users"),
+		semconv.DBStatementKey.String(`SELECT id, username, password_hash FROM users WHERE username = ?`),
+		attribute.Bool("found", false),
+	} {
+		if got, ok := attrs.Value(want.Key); !ok || got != want.Value {
+			t.Errorf("got %s=%v, want %v", want.Key, got.Emit(), want.Value.Emit())
+		}
+	}
+}
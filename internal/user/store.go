@@ -0,0 +1,230 @@
+package user
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type Store interface {
+	// Add returns ErrAlreadyExists when the id or the username is taken.
+	Add(ctx context.Context, user User) error
+	// GetByName returns ErrNotFound when there is no user with username.
+	GetByName(ctx context.Context, username string) (User, error)
+	GetById(ctx context.Context, id string) (User, error)
+	// Update replaces the user with the same id, returning ErrNotFound when
+	// there is none and ErrAlreadyExists when the new username is taken.
+	Update(ctx context.Context, user User) error
+	Delete(ctx context.Context, id string) error
+	// List returns up to limit users ordered by id, starting after the id
+	// afterId, or from the first user when it is empty.
+	List(ctx context.Context, afterId string, limit int) ([]User, error)
+}
+
+type Operation string
+
+const (
+	OperationAdd       Operation = "add"
+	OperationGetByName Operation = "get_by_name"
+	OperationGetById   Operation = "get_by_id"
+	OperationUpdate    Operation = "update"
+	OperationDelete    Operation = "delete"
+	OperationList      Operation = "list"
+)
+
+const storeBackendKey = attribute.Key("store.backend")
+
+// MemoryStore keeps the users in a map, they are lost on restart.
+type MemoryStore struct {
+	users  userIndex
+	mu     sync.RWMutex
+	tracer trace.Tracer
+}
+
+var _ Store = (*MemoryStore)(nil)
+
+func NewMemoryStore() *MemoryStore {
+	tracer := otel.Tracer("user-store")
+	return &MemoryStore{
+		users:  newUserIndex(),
+		tracer: tracer,
+	}
+}
+
+func (us *MemoryStore) newSpan(ctx context.Context, op Operation) (context.Context, trace.Span) {
+	ctx, span := us.tracer.Start(ctx, string(op))
+	span.SetAttributes(storeBackendKey.String("memory"))
+	return ctx, span
+}
+
+func (us *MemoryStore) Add(ctx context.Context, user User) error {
+	_, span := us.newSpan(ctx, OperationAdd)
+	span.SetAttributes(attribute.String("user_id", user.Id), attribute.String("username", user.Username))
+	defer span.End()
+
+	us.mu.Lock()
+	defer us.mu.Unlock()
+	return spanError(span, us.users.add(user))
+}
+
+func (us *MemoryStore) GetByName(ctx context.Context, username string) (User, error) {
+	_, span := us.newSpan(ctx, OperationGetByName)
+	span.SetAttributes(attribute.String("username", username))
+	defer span.End()
+
+	us.mu.RLock()
+	defer us.mu.RUnlock()
+	usr, err := us.users.getByName(username)
+	span.SetAttributes(attribute.Bool("found", err == nil))
+	return usr, spanError(span, err)
+}
+
+func (us *MemoryStore) GetById(ctx context.Context, id string) (User, error) {
+	_, span := us.newSpan(ctx, OperationGetById)
+	span.SetAttributes(attribute.String("user_id", id))
+	defer span.End()
+
+	us.mu.RLock()
+	defer us.mu.RUnlock()
+	usr, err := us.users.getById(id)
+	span.SetAttributes(attribute.Bool("found", err == nil))
+	return usr, spanError(span, err)
+}
+
+func (us *MemoryStore) Update(ctx context.Context, user User) error {
+	_, span := us.newSpan(ctx, OperationUpdate)
+	span.SetAttributes(attribute.String("user_id", user.Id), attribute.String("username", user.Username))
+	defer span.End()
+
+	us.mu.Lock()
+	defer us.mu.Unlock()
+	return spanError(span, us.users.update(user))
+}
+
+func (us *MemoryStore) Delete(ctx context.Context, id string) error {
+	_, span := us.newSpan(ctx, OperationDelete)
+	span.SetAttributes(attribute.String("user_id", id))
+	defer span.End()
+
+	us.mu.Lock()
+	defer us.mu.Unlock()
+	return spanError(span, us.users.delete(id))
+}
+
+func (us *MemoryStore) List(ctx context.Context, afterId string, limit int) ([]User, error) {
+	_, span := us.newSpan(ctx, OperationList)
+	span.SetAttributes(attribute.String("after_id", afterId), attribute.Int("limit", limit))
+	defer span.End()
+
+	us.mu.RLock()
+	defer us.mu.RUnlock()
+	return us.users.list(afterId, limit), nil
+}
+
+// userIndex holds the users of the map backed stores, indexed by id and by
+// username. It is not safe for concurrent use.
+type userIndex struct {
+	byId   map[string]User
+	byName map[string]string
+}
+
+func newUserIndex() userIndex {
+	return userIndex{
+		byId:   make(map[string]User),
+		byName: make(map[string]string),
+	}
+}
+
+func (ix userIndex) clone() userIndex {
+	c := newUserIndex()
+	for id, usr := range ix.byId {
+		c.byId[id] = usr
+		c.byName[usr.Username] = id
+	}
+	return c
+}
+
+func (ix userIndex) getById(id string) (User, error) {
+	usr, ok := ix.byId[id]
+	if !ok {
+		return User{}, ErrNotFound
+	}
+	return usr, nil
+}
+
+func (ix userIndex) getByName(username string) (User, error) {
+	id, ok := ix.byName[username]
+	if !ok {
+		return User{}, ErrNotFound
+	}
+	return ix.byId[id], nil
+}
+
+func (ix userIndex) add(user User) error {
+	if _, ok := ix.byId[user.Id]; ok {
+		return ErrAlreadyExists
+	}
+	if _, ok := ix.byName[user.Username]; ok {
+		return ErrAlreadyExists
+	}
+	ix.byId[user.Id] = user
+	ix.byName[user.Username] = user.Id
+	return nil
+}
+
+func (ix userIndex) update(user User) error {
+	prev, ok := ix.byId[user.Id]
+	if !ok {
+		return ErrNotFound
+	}
+	if id, ok := ix.byName[user.Username]; ok && id != user.Id {
+		return ErrAlreadyExists
+	}
+	delete(ix.byName, prev.Username)
+	ix.byId[user.Id] = user
+	ix.byName[user.Username] = user.Id
+	return nil
+}
+
+func (ix userIndex) delete(id string) error {
+	usr, ok := ix.byId[id]
+	if !ok {
+		return ErrNotFound
+	}
+	delete(ix.byId, id)
+	delete(ix.byName, usr.Username)
+	return nil
+}
+
+func (ix userIndex) list(afterId string, limit int) []User {
+	ids := make([]string, 0, len(ix.byId))
+	for id := range ix.byId {
+		if id > afterId {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	if len(ids) > limit {
+		ids = ids[:limit]
+	}
+
+	users := make([]User, 0, len(ids))
+	for _, id := range ids {
+		users = append(users, ix.byId[id])
+	}
+	return users
+}
+
+// spanError marks span as failed when err is set and returns err.
+func spanError(span trace.Span, err error) error {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return err
+}
@@ -0,0 +1,75 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// testStore checks the Store contract, which all the stores must satisfy.
+func testStore(t *testing.T, store Store) {
+	t.Helper()
+	ctx := context.Background()
+
+	for _, id := range []string{"3", "1", "2"} {
+		if err := store.Add(ctx, User{Id: id, Username: "user" + id, PasswordHash: "hash" + id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := store.Add(ctx, User{Id: "1", Username: "other"}); !errors.Is(err, ErrAlreadyExists) {
+		t.Errorf("got %v for a duplicate id, want ErrAlreadyExists", err)
+	}
+	if err := store.Add(ctx, User{Id: "4", Username: "user1"}); !errors.Is(err, ErrAlreadyExists) {
+		t.Errorf("got %v for a duplicate username, want ErrAlreadyExists", err)
+	}
+
+	usr, err := store.GetByName(ctx, "user2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (User{Id: "2", Username: "user2", PasswordHash: "hash2"}); usr != want {
+		t.Errorf("got %+v, want %+v", usr, want)
+	}
+	if _, err := store.GetByName(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("got %v for a missing username, want ErrNotFound", err)
+	}
+
+	if err := store.Update(ctx, User{Id: "2", Username: "renamed", PasswordHash: "hash"}); err != nil {
+		t.Fatal(err)
+	}
+	if usr, err := store.GetById(ctx, "2"); err != nil || usr.Username != "renamed" {
+		t.Errorf("got %+v, %v after the update", usr, err)
+	}
+	if err := store.Update(ctx, User{Id: "4", Username: "user4"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("got %v updating a missing user, want ErrNotFound", err)
+	}
+	if err := store.Update(ctx, User{Id: "2", Username: "user3"}); !errors.Is(err, ErrAlreadyExists) {
+		t.Errorf("got %v renaming to a taken username, want ErrAlreadyExists", err)
+	}
+
+	users, err := store.List(ctx, "1", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 2 || users[0].Id != "2" || users[1].Id != "3" {
+		t.Errorf("got %+v, want the users after id 1 in id order", users)
+	}
+	if users, err := store.List(ctx, "", 1); err != nil || len(users) != 1 || users[0].Id != "1" {
+		t.Errorf("got %+v, %v for the first page of 1", users, err)
+	}
+
+	if err := store.Delete(ctx, "1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Delete(ctx, "1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("got %v deleting a deleted user, want ErrNotFound", err)
+	}
+	if _, err := store.GetById(ctx, "1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("got %v for a deleted user, want ErrNotFound", err)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, NewMemoryStore())
+}
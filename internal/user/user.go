@@ -2,14 +2,19 @@ package user
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
-	"sync"
+	"toy/internal/credentials"
+	"toy/internal/logging"
 	"toy/schema/usergrpc"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
-	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
 )
 
 type Server struct {
@@ -20,25 +25,92 @@ type Server struct {
 func (s *Server) GetUserByName(ctx context.Context, req *usergrpc.GetUserByNameReq) (*usergrpc.GetUserByNameResponse, error) {
 	usr, err := s.Svc.GetUserByName(ctx, req.Name)
 	if err != nil {
-		return nil, err
+		return nil, toStatus(err)
 	}
 
-	return &usergrpc.GetUserByNameResponse{User: &usergrpc.User{
-		Id:           usr.Id,
-		Username:     usr.Username,
-		PasswordHash: usr.PasswordHash,
-	}}, nil
+	return &usergrpc.GetUserByNameResponse{User: usr.proto()}, nil
 }
 
+func (s *Server) GetUserById(ctx context.Context, req *usergrpc.GetUserByIdReq) (*usergrpc.GetUserByIdResponse, error) {
+	usr, err := s.Svc.GetUserById(ctx, req.Id)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &usergrpc.GetUserByIdResponse{User: usr.proto()}, nil
+}
+
+func (s *Server) CreateUser(ctx context.Context, req *usergrpc.CreateUserReq) (*usergrpc.CreateUserResponse, error) {
+	usr, err := s.Svc.CreateUser(ctx, req.Username, req.Password)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &usergrpc.CreateUserResponse{User: usr.proto()}, nil
+}
+
+func (s *Server) UpdateUser(ctx context.Context, req *usergrpc.UpdateUserReq) (*usergrpc.UpdateUserResponse, error) {
+	usr, err := s.Svc.UpdateUser(ctx, req.Id, req.Username, req.Password)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &usergrpc.UpdateUserResponse{User: usr.proto()}, nil
+}
+
+func (s *Server) DeleteUser(ctx context.Context, req *usergrpc.DeleteUserReq) (*usergrpc.DeleteUserResponse, error) {
+	if err := s.Svc.DeleteUser(ctx, req.Id); err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &usergrpc.DeleteUserResponse{}, nil
+}
+
+func (s *Server) ListUsers(ctx context.Context, req *usergrpc.ListUsersReq) (*usergrpc.ListUsersResponse, error) {
+	users, next, err := s.Svc.ListUsers(ctx, int(req.PageSize), req.PageToken)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	resp := &usergrpc.ListUsersResponse{NextPageToken: next}
+	for _, usr := range users {
+		resp.Users = append(resp.Users, usr.proto())
+	}
+	return resp, nil
+}
+
+func (s *Server) VerifyPassword(ctx context.Context, req *usergrpc.VerifyPasswordReq) (*usergrpc.VerifyPasswordResponse, error) {
+	usr, match, err := s.Svc.VerifyPassword(ctx, req.Username, req.Password)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	if !match {
+		return &usergrpc.VerifyPasswordResponse{}, nil
+	}
+
+	return &usergrpc.VerifyPasswordResponse{Match: true, User: usr.proto()}, nil
+}
+
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 1000
+)
+
+// dummyHash is compared against the password of users that do not exist. It
+// has the cost of credentials.GenerateBCrypt.
+const dummyHash = "$2a$10$RZmQceFjz.Rr1GtBQ15bcOpNxz8TPc6h1C6HTj8tD7EU0f6zxgedO"
+
 type service struct {
-	store  *userStore
+	store  Store
 	tracer trace.Tracer
+	logger logging.Logger
 }
 
-func NewService(store *userStore) service {
+func NewService(store Store, logger logging.Logger) service {
 	return service{
 		store:  store,
 		tracer: otel.GetTracerProvider().Tracer("user-service"),
+		logger: logger,
 	}
 }
 
@@ -46,68 +118,268 @@ func (s service) GetUserByName(ctx context.Context, username string) (User, erro
 	ctxSpan, span := s.tracer.Start(ctx, "GetUserByName")
 	span.SetAttributes(attribute.String("username", username))
 	defer span.End()
-	return s.store.GetByName(ctxSpan, username)
-}
 
-type User struct {
-	Id           string
-	Username     string
-	PasswordHash string
+	if username == "" {
+		span.RecordError(ErrInvalidUsername)
+		span.SetStatus(codes.Error, "invalid username")
+		return User{}, ErrInvalidUsername
+	}
+
+	usr, err := s.store.GetByName(ctxSpan, username)
+	if err != nil {
+		s.logger.Warn(ctxSpan, "failed to get user by name", zap.String("username", username), zap.Error(err))
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to get user by name")
+		return User{}, err
+	}
+
+	s.logger.Debug(ctxSpan, "got user by name", zap.String("username", username), zap.String("user_id", usr.Id))
+	return usr, nil
 }
 
-type Operation string
+func (s service) GetUserById(ctx context.Context, id string) (User, error) {
+	ctxSpan, span := s.tracer.Start(ctx, "GetUserById")
+	span.SetAttributes(attribute.String("user_id", id))
+	defer span.End()
 
-const (
-	OperationAdd       Operation = "add"
-	OperationGetByName Operation = "get_by_name"
-)
+	if id == "" {
+		return User{}, s.fail(ctxSpan, span, "invalid user id", ErrInvalidId)
+	}
 
-type userStore struct {
-	users  map[string]User
-	mu     sync.RWMutex
-	tracer trace.Tracer
+	usr, err := s.store.GetById(ctxSpan, id)
+	if err != nil {
+		return User{}, s.fail(ctxSpan, span, "failed to get user by id", err, zap.String("user_id", id))
+	}
+
+	s.logger.Debug(ctxSpan, "got user by id", zap.String("user_id", id))
+	return usr, nil
 }
 
-func NewStore() *userStore {
-	tracer := otel.Tracer("user-store")
-	return &userStore{
-		users:  make(map[string]User),
-		tracer: tracer,
+func (s service) CreateUser(ctx context.Context, username, password string) (User, error) {
+	ctxSpan, span := s.tracer.Start(ctx, "CreateUser")
+	span.SetAttributes(attribute.String("username", username))
+	defer span.End()
+
+	switch {
+	case username == "":
+		return User{}, s.fail(ctxSpan, span, "invalid username", ErrInvalidUsername)
+	case password == "":
+		return User{}, s.fail(ctxSpan, span, "invalid password", ErrInvalidPassword)
 	}
+
+	hash, err := s.hashPassword(ctxSpan, password)
+	if err != nil {
+		return User{}, s.fail(ctxSpan, span, "failed to hash password", err)
+	}
+
+	id, err := newId()
+	if err != nil {
+		return User{}, s.fail(ctxSpan, span, "failed to generate user id", err)
+	}
+
+	usr := User{Id: id, Username: username, PasswordHash: hash}
+	span.SetAttributes(attribute.String("user_id", id))
+	if err := s.store.Add(ctxSpan, usr); err != nil {
+		return User{}, s.fail(ctxSpan, span, "failed to create user", err, zap.String("username", username))
+	}
+
+	s.logger.Info(ctxSpan, "created user", zap.String("username", username), zap.String("user_id", id))
+	return usr, nil
 }
 
-func (us *userStore) newSpan(ctx context.Context, name string) (context.Context, trace.Span) {
-	ctx, span := us.tracer.Start(ctx, name)
-	span.SetAttributes(
-		semconv.DBSystemPostgreSQL,
-	)
-	return ctx, span
+// UpdateUser changes the username and the password of the user, leaving
+// them unchanged when empty.
+func (s service) UpdateUser(ctx context.Context, id, username, password string) (User, error) {
+	ctxSpan, span := s.tracer.Start(ctx, "UpdateUser")
+	span.SetAttributes(attribute.String("user_id", id))
+	defer span.End()
+
+	if id == "" {
+		return User{}, s.fail(ctxSpan, span, "invalid user id", ErrInvalidId)
+	}
+
+	usr, err := s.store.GetById(ctxSpan, id)
+	if err != nil {
+		return User{}, s.fail(ctxSpan, span, "failed to get user by id", err, zap.String("user_id", id))
+	}
+
+	if username != "" {
+		usr.Username = username
+	}
+	if password != "" {
+		span.SetAttributes(attribute.Bool("password_changed", true))
+		if usr.PasswordHash, err = s.hashPassword(ctxSpan, password); err != nil {
+			return User{}, s.fail(ctxSpan, span, "failed to hash password", err)
+		}
+	}
+
+	if err := s.store.Update(ctxSpan, usr); err != nil {
+		return User{}, s.fail(ctxSpan, span, "failed to update user", err, zap.String("user_id", id))
+	}
+
+	s.logger.Info(ctxSpan, "updated user", zap.String("user_id", id), zap.Bool("password_changed", password != ""))
+	return usr, nil
 }
 
-func (us *userStore) Add(ctx context.Context, user User) error {
-	_, span := us.newSpan(ctx, string(OperationAdd))
-	span.SetAttributes(attribute.String("user_id", user.Id), attribute.String("username", user.Username))
+func (s service) DeleteUser(ctx context.Context, id string) error {
+	ctxSpan, span := s.tracer.Start(ctx, "DeleteUser")
+	span.SetAttributes(attribute.String("user_id", id))
 	defer span.End()
 
-	us.mu.Lock()
-	defer us.mu.Unlock()
-	us.users[user.Username] = user
+	if id == "" {
+		return s.fail(ctxSpan, span, "invalid user id", ErrInvalidId)
+	}
+
+	if err := s.store.Delete(ctxSpan, id); err != nil {
+		return s.fail(ctxSpan, span, "failed to delete user", err, zap.String("user_id", id))
+	}
+
+	s.logger.Info(ctxSpan, "deleted user", zap.String("user_id", id))
 	return nil
 }
 
-func (us *userStore) GetByName(ctx context.Context, username string) (User, error) {
-	_, span := us.newSpan(ctx, string(OperationGetByName))
+// ListUsers returns a page of users ordered by id and the token of the next
+// page, which is empty on the last page.
+func (s service) ListUsers(ctx context.Context, pageSize int, pageToken string) ([]User, string, error) {
+	ctxSpan, span := s.tracer.Start(ctx, "ListUsers")
+	defer span.End()
+
+	switch {
+	case pageSize <= 0:
+		pageSize = DefaultPageSize
+	case pageSize > MaxPageSize:
+		pageSize = MaxPageSize
+	}
+	span.SetAttributes(attribute.Int("page_size", pageSize))
+
+	afterId, err := decodePageToken(pageToken)
+	if err != nil {
+		return nil, "", s.fail(ctxSpan, span, "invalid page token", err)
+	}
+
+	// One more user than asked for tells whether there is a next page.
+	users, err := s.store.List(ctxSpan, afterId, pageSize+1)
+	if err != nil {
+		return nil, "", s.fail(ctxSpan, span, "failed to list users", err)
+	}
+
+	var next string
+	if len(users) > pageSize {
+		users = users[:pageSize]
+		next = encodePageToken(users[pageSize-1].Id)
+	}
+
+	span.SetAttributes(attribute.Int("users", len(users)), attribute.Bool("last_page", next == ""))
+	s.logger.Debug(ctxSpan, "listed users", zap.Int("users", len(users)))
+	return users, next, nil
+}
+
+// VerifyPassword reports whether password matches the password of the user,
+// returning the user when it does.
+func (s service) VerifyPassword(ctx context.Context, username, password string) (User, bool, error) {
+	ctxSpan, span := s.tracer.Start(ctx, "VerifyPassword")
 	span.SetAttributes(attribute.String("username", username))
-	span.SetAttributes(attribute.Bool("found", true))
 	defer span.End()
 
-	us.mu.RLock()
-	defer us.mu.RUnlock()
-	usr, ok := us.users[username]
-	if !ok {
-		span.SetAttributes(attribute.Bool("found", false))
-		return User{}, errors.New("not found")
+	switch {
+	case username == "":
+		return User{}, false, s.fail(ctxSpan, span, "invalid username", ErrInvalidUsername)
+	case password == "":
+		return User{}, false, s.fail(ctxSpan, span, "invalid password", ErrInvalidPassword)
 	}
 
-	return usr, nil
+	usr, err := s.store.GetByName(ctxSpan, username)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			// Comparing anyway takes as long as for an existing user, so the
+			// response time does not tell which usernames exist.
+			s.comparePassword(ctxSpan, password, dummyHash)
+		}
+		return User{}, false, s.fail(ctxSpan, span, "failed to get user by name", err, zap.String("username", username))
+	}
+
+	match, err := s.comparePassword(ctxSpan, password, usr.PasswordHash)
+	if err != nil {
+		return User{}, false, s.fail(ctxSpan, span, "failed to compare password", err, zap.String("username", username))
+	}
+
+	span.SetAttributes(attribute.Bool("password_match", match))
+	if !match {
+		s.logger.Info(ctxSpan, "password mismatch", zap.String("username", username))
+		return User{}, false, nil
+	}
+
+	s.logger.Debug(ctxSpan, "verified password", zap.String("username", username), zap.String("user_id", usr.Id))
+	return usr, true, nil
+}
+
+func (s service) comparePassword(ctx context.Context, password, hashedPassword string) (bool, error) {
+	_, span := s.tracer.Start(ctx, "password_match")
+	defer span.End()
+
+	valid, err := credentials.CompareBCrypt(password, hashedPassword)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to compare bcrypt hash")
+		return false, err
+	}
+
+	return valid, nil
+}
+
+func (s service) hashPassword(ctx context.Context, password string) (string, error) {
+	_, span := s.tracer.Start(ctx, "password_hash")
+	defer span.End()
+
+	hash, err := credentials.GenerateBCrypt(password)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to hash password")
+		return "", err
+	}
+	return hash, nil
+}
+
+// fail records err on span, logs it with msg and returns it.
+func (s service) fail(ctx context.Context, span trace.Span, msg string, err error, fields ...zap.Field) error {
+	s.logger.Warn(ctx, msg, append(fields, zap.Error(err))...)
+	span.RecordError(err)
+	span.SetStatus(codes.Error, msg)
+	return err
+}
+
+func newId() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Page tokens are the id of the last user of the previous page, encoded so
+// that clients treat them as opaque.
+func encodePageToken(lastId string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(lastId))
+}
+
+func decodePageToken(token string) (string, error) {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return "", ErrInvalidPageToken
+	}
+	return string(b), nil
+}
+
+type User struct {
+	Id           string `json:"id"`
+	Username     string `json:"username"`
+	PasswordHash string `json:"password_hash"`
+}
+
+// proto returns the public projection of u, without the password hash.
+func (u User) proto() *usergrpc.User {
+	return &usergrpc.User{
+		Id:       u.Id,
+		Username: u.Username,
+	}
 }
@@ -0,0 +1,260 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"toy/internal/credentials"
+	"toy/internal/logging"
+	"toy/schema/usergrpc"
+
+	"go.opentelemetry.io/otel"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func newTestServer(t *testing.T) (*Server, *MemoryStore) {
+	t.Helper()
+
+	store := NewMemoryStore()
+	return &Server{Svc: NewService(store, logging.NewNop())}, store
+}
+
+// addUsers adds n users with the ids 000 to n-1 straight to store, skipping
+// the password hashing of CreateUser.
+func addUsers(t *testing.T, store Store, n int) {
+	t.Helper()
+
+	for i := 0; i < n; i++ {
+		id := fmt.Sprintf("%03d", i)
+		if err := store.Add(context.Background(), User{Id: id, Username: "user" + id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestCreateUser(t *testing.T) {
+	srv, store := newTestServer(t)
+	ctx := context.Background()
+
+	resp, err := srv.CreateUser(ctx, &usergrpc.CreateUserReq{Username: "kasutaja", Password: "parool"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.User.Id == "" || resp.User.Username != "kasutaja" {
+		t.Errorf("got %+v, want a new user kasutaja", resp.User)
+	}
+
+	usr, err := store.GetById(ctx, resp.User.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if match, err := credentials.CompareBCrypt("parool", usr.PasswordHash); err != nil || !match {
+		t.Errorf("got %v, %v comparing the stored hash, want a match", match, err)
+	}
+
+	tests := []struct {
+		name     string
+		username string
+		password string
+		want     codes.Code
+	}{
+		{name: "duplicate username", username: "kasutaja", password: "teine", want: codes.AlreadyExists},
+		{name: "no username", password: "parool", want: codes.InvalidArgument},
+		{name: "no password", username: "teine", want: codes.InvalidArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := srv.CreateUser(ctx, &usergrpc.CreateUserReq{Username: tt.username, Password: tt.password})
+			if got := status.Code(err); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateUser(t *testing.T) {
+	const hash = "unchanged"
+
+	tests := []struct {
+		name         string
+		req          *usergrpc.UpdateUserReq
+		want         codes.Code
+		wantUsername string
+		wantPassword string
+	}{
+		{name: "nothing", req: &usergrpc.UpdateUserReq{Id: "1"}, wantUsername: "kasutaja"},
+		{name: "username", req: &usergrpc.UpdateUserReq{Id: "1", Username: "uus"}, wantUsername: "uus"},
+		{name: "password", req: &usergrpc.UpdateUserReq{Id: "1", Password: "uus"}, wantUsername: "kasutaja", wantPassword: "uus"},
+		{name: "taken username", req: &usergrpc.UpdateUserReq{Id: "1", Username: "teine"}, want: codes.AlreadyExists},
+		{name: "missing user", req: &usergrpc.UpdateUserReq{Id: "3", Username: "uus"}, want: codes.NotFound},
+		{name: "no id", req: &usergrpc.UpdateUserReq{Username: "uus"}, want: codes.InvalidArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, store := newTestServer(t)
+			ctx := context.Background()
+			for _, usr := range []User{{Id: "1", Username: "kasutaja", PasswordHash: hash}, {Id: "2", Username: "teine"}} {
+				if err := store.Add(ctx, usr); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			_, err := srv.UpdateUser(ctx, tt.req)
+			if got := status.Code(err); got != tt.want {
+				t.Fatalf("got %s, want %s", got, tt.want)
+			}
+			if tt.want != codes.OK {
+				return
+			}
+
+			usr, err := store.GetById(ctx, "1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if usr.Username != tt.wantUsername {
+				t.Errorf("got username %q, want %q", usr.Username, tt.wantUsername)
+			}
+			if tt.wantPassword == "" {
+				if usr.PasswordHash != hash {
+					t.Errorf("got hash %q, want it unchanged", usr.PasswordHash)
+				}
+			} else if match, err := credentials.CompareBCrypt(tt.wantPassword, usr.PasswordHash); err != nil || !match {
+				t.Errorf("got %v, %v comparing the new hash, want a match", match, err)
+			}
+		})
+	}
+}
+
+func TestDeleteUser(t *testing.T) {
+	srv, store := newTestServer(t)
+	ctx := context.Background()
+	addUsers(t, store, 1)
+
+	if _, err := srv.DeleteUser(ctx, &usergrpc.DeleteUserReq{Id: "000"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := srv.GetUserById(ctx, &usergrpc.GetUserByIdReq{Id: "000"}); status.Code(err) != codes.NotFound {
+		t.Errorf("got %v for the deleted user, want NotFound", err)
+	}
+	if _, err := srv.DeleteUser(ctx, &usergrpc.DeleteUserReq{Id: "000"}); status.Code(err) != codes.NotFound {
+		t.Errorf("got %v deleting it again, want NotFound", err)
+	}
+	if _, err := srv.DeleteUser(ctx, &usergrpc.DeleteUserReq{}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("got %v without an id, want InvalidArgument", err)
+	}
+}
+
+func TestListUsersPageSize(t *testing.T) {
+	srv, store := newTestServer(t)
+	addUsers(t, store, MaxPageSize+1)
+
+	tests := []struct {
+		pageSize int32
+		want     int
+	}{
+		{pageSize: 0, want: DefaultPageSize},
+		{pageSize: -1, want: DefaultPageSize},
+		{pageSize: 10, want: 10},
+		{pageSize: MaxPageSize + 1, want: MaxPageSize},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.pageSize), func(t *testing.T) {
+			resp, err := srv.ListUsers(context.Background(), &usergrpc.ListUsersReq{PageSize: tt.pageSize})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(resp.Users) != tt.want {
+				t.Errorf("got %d users, want %d", len(resp.Users), tt.want)
+			}
+		})
+	}
+}
+
+func TestListUsersPages(t *testing.T) {
+	tests := []struct {
+		name  string
+		users int
+		want  []int
+	}{
+		{name: "empty", users: 0, want: []int{0}},
+		{name: "partial last page", users: 5, want: []int{2, 2, 1}},
+		// The last page is full, the extra user fetched must not make it
+		// look like there is another one.
+		{name: "full last page", users: 4, want: []int{2, 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, store := newTestServer(t)
+			addUsers(t, store, tt.users)
+
+			var pages []int
+			var seen int
+			req := &usergrpc.ListUsersReq{PageSize: 2}
+			for {
+				resp, err := srv.ListUsers(context.Background(), req)
+				if err != nil {
+					t.Fatal(err)
+				}
+				for i, usr := range resp.Users {
+					if want := fmt.Sprintf("%03d", seen+i); usr.Id != want {
+						t.Errorf("got user %s, want %s", usr.Id, want)
+					}
+				}
+				seen += len(resp.Users)
+				pages = append(pages, len(resp.Users))
+
+				if resp.NextPageToken == "" || len(pages) > len(tt.want) {
+					break
+				}
+				req.PageToken = resp.NextPageToken
+			}
+
+			if fmt.Sprint(pages) != fmt.Sprint(tt.want) {
+				t.Errorf("got pages of %v users, want %v", pages, tt.want)
+			}
+		})
+	}
+}
+
+func TestListUsersInvalidPageToken(t *testing.T) {
+	srv, _ := newTestServer(t)
+
+	_, err := srv.ListUsers(context.Background(), &usergrpc.ListUsersReq{PageToken: "not base64!"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("got %v, want InvalidArgument", err)
+	}
+}
+
+func TestVerifyPasswordUnknownUser(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(tp)
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	srv, _ := newTestServer(t)
+	_, err := srv.VerifyPassword(context.Background(), &usergrpc.VerifyPasswordReq{Username: "missing", Password: "parool"})
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("got %v, want NotFound", err)
+	}
+
+	// The password is compared against dummyHash, as long as for a user
+	// that exists.
+	for _, span := range exporter.GetSpans() {
+		if span.Name == "password_match" {
+			if span.Status.Code != otelcodes.Unset {
+				t.Errorf("got status %v comparing against dummyHash", span.Status)
+			}
+			return
+		}
+	}
+	t.Error("the password was not compared")
+}
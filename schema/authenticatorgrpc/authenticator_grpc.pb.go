@@ -19,6 +19,9 @@ const _ = grpc.SupportPackageIsVersion7
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type AuthenticatorClient interface {
 	AuthenticatePassword(ctx context.Context, in *AuthenticatePasswordReq, opts ...grpc.CallOption) (*AuthenticatePasswordResponse, error)
+	// ValidateToken verifies a token issued by AuthenticatePassword and
+	// returns its claims.
+	ValidateToken(ctx context.Context, in *ValidateTokenReq, opts ...grpc.CallOption) (*ValidateTokenResponse, error)
 }
 
 type authenticatorClient struct {
@@ -38,11 +41,23 @@ func (c *authenticatorClient) AuthenticatePassword(ctx context.Context, in *Auth
 	return out, nil
 }
 
+func (c *authenticatorClient) ValidateToken(ctx context.Context, in *ValidateTokenReq, opts ...grpc.CallOption) (*ValidateTokenResponse, error) {
+	out := new(ValidateTokenResponse)
+	err := c.cc.Invoke(ctx, "/toy.Authenticator/ValidateToken", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // AuthenticatorServer is the server API for Authenticator service.
 // All implementations must embed UnimplementedAuthenticatorServer
 // for forward compatibility
 type AuthenticatorServer interface {
 	AuthenticatePassword(context.Context, *AuthenticatePasswordReq) (*AuthenticatePasswordResponse, error)
+	// ValidateToken verifies a token issued by AuthenticatePassword and
+	// returns its claims.
+	ValidateToken(context.Context, *ValidateTokenReq) (*ValidateTokenResponse, error)
 	mustEmbedUnimplementedAuthenticatorServer()
 }
 
@@ -53,6 +68,9 @@ type UnimplementedAuthenticatorServer struct {
 func (UnimplementedAuthenticatorServer) AuthenticatePassword(context.Context, *AuthenticatePasswordReq) (*AuthenticatePasswordResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method AuthenticatePassword not implemented")
 }
+func (UnimplementedAuthenticatorServer) ValidateToken(context.Context, *ValidateTokenReq) (*ValidateTokenResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ValidateToken not implemented")
+}
 func (UnimplementedAuthenticatorServer) mustEmbedUnimplementedAuthenticatorServer() {}
 
 // UnsafeAuthenticatorServer may be embedded to opt out of forward compatibility for this service.
@@ -84,6 +102,24 @@ func _Authenticator_AuthenticatePassword_Handler(srv interface{}, ctx context.Co
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Authenticator_ValidateToken_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ValidateTokenReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthenticatorServer).ValidateToken(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/toy.Authenticator/ValidateToken",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthenticatorServer).ValidateToken(ctx, req.(*ValidateTokenReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Authenticator_ServiceDesc is the grpc.ServiceDesc for Authenticator service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -95,6 +131,10 @@ var Authenticator_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "AuthenticatePassword",
 			Handler:    _Authenticator_AuthenticatePassword_Handler,
 		},
+		{
+			MethodName: "ValidateToken",
+			Handler:    _Authenticator_ValidateToken_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "schema/authenticator.proto",
@@ -20,6 +20,61 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type Operation_Op int32
+
+const (
+	Operation_OP_UNSPECIFIED Operation_Op = 0
+	Operation_OP_ADD         Operation_Op = 1
+	Operation_OP_SUBSTRACT   Operation_Op = 2
+	Operation_OP_MULTIPLY    Operation_Op = 3
+	Operation_OP_DIVIDE      Operation_Op = 4
+)
+
+// Enum value maps for Operation_Op.
+var (
+	Operation_Op_name = map[int32]string{
+		0: "OP_UNSPECIFIED",
+		1: "OP_ADD",
+		2: "OP_SUBSTRACT",
+		3: "OP_MULTIPLY",
+		4: "OP_DIVIDE",
+	}
+	Operation_Op_value = map[string]int32{
+		"OP_UNSPECIFIED": 0,
+		"OP_ADD":         1,
+		"OP_SUBSTRACT":   2,
+		"OP_MULTIPLY":    3,
+		"OP_DIVIDE":      4,
+	}
+)
+
+func (x Operation_Op) Enum() *Operation_Op {
+	p := new(Operation_Op)
+	*p = x
+	return p
+}
+
+func (x Operation_Op) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Operation_Op) Descriptor() protoreflect.EnumDescriptor {
+	return file_schema_calculator_proto_enumTypes[0].Descriptor()
+}
+
+func (Operation_Op) Type() protoreflect.EnumType {
+	return &file_schema_calculator_proto_enumTypes[0]
+}
+
+func (x Operation_Op) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Operation_Op.Descriptor instead.
+func (Operation_Op) EnumDescriptor() ([]byte, []int) {
+	return file_schema_calculator_proto_rawDescGZIP(), []int{5, 0}
+}
+
 type Values struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -169,6 +224,296 @@ func (x *AddValue) GetValue() int32 {
 	return 0
 }
 
+type MultiplyValue struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Value int32 `protobuf:"varint,1,opt,name=value,proto3" json:"value,omitempty"`
+}
+
+func (x *MultiplyValue) Reset() {
+	*x = MultiplyValue{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_schema_calculator_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *MultiplyValue) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MultiplyValue) ProtoMessage() {}
+
+func (x *MultiplyValue) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_calculator_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MultiplyValue.ProtoReflect.Descriptor instead.
+func (*MultiplyValue) Descriptor() ([]byte, []int) {
+	return file_schema_calculator_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *MultiplyValue) GetValue() int32 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+type DivideValue struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Value int32 `protobuf:"varint,1,opt,name=value,proto3" json:"value,omitempty"`
+}
+
+func (x *DivideValue) Reset() {
+	*x = DivideValue{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_schema_calculator_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DivideValue) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DivideValue) ProtoMessage() {}
+
+func (x *DivideValue) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_calculator_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DivideValue.ProtoReflect.Descriptor instead.
+func (*DivideValue) Descriptor() ([]byte, []int) {
+	return file_schema_calculator_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *DivideValue) GetValue() int32 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+type Operation struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Op    Operation_Op `protobuf:"varint,1,opt,name=op,proto3,enum=toy.Operation_Op" json:"op,omitempty"`
+	Value int32        `protobuf:"varint,2,opt,name=value,proto3" json:"value,omitempty"`
+}
+
+func (x *Operation) Reset() {
+	*x = Operation{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_schema_calculator_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Operation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Operation) ProtoMessage() {}
+
+func (x *Operation) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_calculator_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Operation.ProtoReflect.Descriptor instead.
+func (*Operation) Descriptor() ([]byte, []int) {
+	return file_schema_calculator_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Operation) GetOp() Operation_Op {
+	if x != nil {
+		return x.Op
+	}
+	return Operation_OP_UNSPECIFIED
+}
+
+func (x *Operation) GetValue() int32 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+type EvaluateValue struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Value int32 `protobuf:"varint,1,opt,name=value,proto3" json:"value,omitempty"`
+}
+
+func (x *EvaluateValue) Reset() {
+	*x = EvaluateValue{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_schema_calculator_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *EvaluateValue) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EvaluateValue) ProtoMessage() {}
+
+func (x *EvaluateValue) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_calculator_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EvaluateValue.ProtoReflect.Descriptor instead.
+func (*EvaluateValue) Descriptor() ([]byte, []int) {
+	return file_schema_calculator_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *EvaluateValue) GetValue() int32 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+type Expression struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Expression string `protobuf:"bytes,1,opt,name=expression,proto3" json:"expression,omitempty"`
+}
+
+func (x *Expression) Reset() {
+	*x = Expression{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_schema_calculator_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Expression) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Expression) ProtoMessage() {}
+
+func (x *Expression) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_calculator_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Expression.ProtoReflect.Descriptor instead.
+func (*Expression) Descriptor() ([]byte, []int) {
+	return file_schema_calculator_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Expression) GetExpression() string {
+	if x != nil {
+		return x.Expression
+	}
+	return ""
+}
+
+type ExpressionValue struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Value int32 `protobuf:"varint,1,opt,name=value,proto3" json:"value,omitempty"`
+}
+
+func (x *ExpressionValue) Reset() {
+	*x = ExpressionValue{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_schema_calculator_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ExpressionValue) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExpressionValue) ProtoMessage() {}
+
+func (x *ExpressionValue) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_calculator_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExpressionValue.ProtoReflect.Descriptor instead.
+func (*ExpressionValue) Descriptor() ([]byte, []int) {
+	return file_schema_calculator_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ExpressionValue) GetValue() int32 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
 var File_schema_calculator_proto protoreflect.FileDescriptor
 
 var file_schema_calculator_proto_rawDesc = []byte{
@@ -180,15 +525,50 @@ var file_schema_calculator_proto_rawDesc = []byte{
 	0x74, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18,
 	0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x22, 0x20, 0x0a, 0x08,
 	0x41, 0x64, 0x64, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75,
-	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x32, 0x5e,
-	0x0a, 0x0a, 0x43, 0x61, 0x6c, 0x63, 0x75, 0x6c, 0x61, 0x74, 0x6f, 0x72, 0x12, 0x2d, 0x0a, 0x09,
-	0x53, 0x75, 0x62, 0x73, 0x74, 0x72, 0x61, 0x63, 0x74, 0x12, 0x0b, 0x2e, 0x74, 0x6f, 0x79, 0x2e,
-	0x56, 0x61, 0x6c, 0x75, 0x65, 0x73, 0x1a, 0x13, 0x2e, 0x74, 0x6f, 0x79, 0x2e, 0x53, 0x75, 0x62,
-	0x73, 0x74, 0x72, 0x61, 0x63, 0x74, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x21, 0x0a, 0x03, 0x41,
-	0x64, 0x64, 0x12, 0x0b, 0x2e, 0x74, 0x6f, 0x79, 0x2e, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x73, 0x1a,
-	0x0d, 0x2e, 0x74, 0x6f, 0x79, 0x2e, 0x41, 0x64, 0x64, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x42, 0x17,
-	0x5a, 0x15, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2f, 0x63, 0x61, 0x6c, 0x63, 0x75, 0x6c, 0x61,
-	0x74, 0x6f, 0x72, 0x67, 0x72, 0x70, 0x63, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x22, 0x25,
+	0x0a, 0x0d, 0x4d, 0x75, 0x6c, 0x74, 0x69, 0x70, 0x6c, 0x79, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x12,
+	0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05,
+	0x76, 0x61, 0x6c, 0x75, 0x65, 0x22, 0x23, 0x0a, 0x0b, 0x44, 0x69, 0x76, 0x69, 0x64, 0x65, 0x56,
+	0x61, 0x6c, 0x75, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x05, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x22, 0x9c, 0x01, 0x0a, 0x09, 0x4f,
+	0x70, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x21, 0x0a, 0x02, 0x6f, 0x70, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x0e, 0x32, 0x11, 0x2e, 0x74, 0x6f, 0x79, 0x2e, 0x4f, 0x70, 0x65, 0x72, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x4f, 0x70, 0x52, 0x02, 0x6f, 0x70, 0x12, 0x14, 0x0a, 0x05, 0x76,
+	0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75,
+	0x65, 0x22, 0x56, 0x0a, 0x02, 0x4f, 0x70, 0x12, 0x12, 0x0a, 0x0e, 0x4f, 0x50, 0x5f, 0x55, 0x4e,
+	0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x0a, 0x0a, 0x06, 0x4f,
+	0x50, 0x5f, 0x41, 0x44, 0x44, 0x10, 0x01, 0x12, 0x10, 0x0a, 0x0c, 0x4f, 0x50, 0x5f, 0x53, 0x55,
+	0x42, 0x53, 0x54, 0x52, 0x41, 0x43, 0x54, 0x10, 0x02, 0x12, 0x0f, 0x0a, 0x0b, 0x4f, 0x50, 0x5f,
+	0x4d, 0x55, 0x4c, 0x54, 0x49, 0x50, 0x4c, 0x59, 0x10, 0x03, 0x12, 0x0d, 0x0a, 0x09, 0x4f, 0x50,
+	0x5f, 0x44, 0x49, 0x56, 0x49, 0x44, 0x45, 0x10, 0x04, 0x22, 0x25, 0x0a, 0x0d, 0x45, 0x76, 0x61,
+	0x6c, 0x75, 0x61, 0x74, 0x65, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61,
+	0x6c, 0x75, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65,
+	0x22, 0x2c, 0x0a, 0x0a, 0x45, 0x78, 0x70, 0x72, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x1e,
+	0x0a, 0x0a, 0x65, 0x78, 0x70, 0x72, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x0a, 0x65, 0x78, 0x70, 0x72, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x22, 0x27,
+	0x0a, 0x0f, 0x45, 0x78, 0x70, 0x72, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x56, 0x61, 0x6c, 0x75,
+	0x65, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05,
+	0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x32, 0xa5, 0x02, 0x0a, 0x0a, 0x43, 0x61, 0x6c, 0x63,
+	0x75, 0x6c, 0x61, 0x74, 0x6f, 0x72, 0x12, 0x2d, 0x0a, 0x09, 0x53, 0x75, 0x62, 0x73, 0x74, 0x72,
+	0x61, 0x63, 0x74, 0x12, 0x0b, 0x2e, 0x74, 0x6f, 0x79, 0x2e, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x73,
+	0x1a, 0x13, 0x2e, 0x74, 0x6f, 0x79, 0x2e, 0x53, 0x75, 0x62, 0x73, 0x74, 0x72, 0x61, 0x63, 0x74,
+	0x56, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x21, 0x0a, 0x03, 0x41, 0x64, 0x64, 0x12, 0x0b, 0x2e, 0x74,
+	0x6f, 0x79, 0x2e, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x73, 0x1a, 0x0d, 0x2e, 0x74, 0x6f, 0x79, 0x2e,
+	0x41, 0x64, 0x64, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x2b, 0x0a, 0x08, 0x4d, 0x75, 0x6c, 0x74,
+	0x69, 0x70, 0x6c, 0x79, 0x12, 0x0b, 0x2e, 0x74, 0x6f, 0x79, 0x2e, 0x56, 0x61, 0x6c, 0x75, 0x65,
+	0x73, 0x1a, 0x12, 0x2e, 0x74, 0x6f, 0x79, 0x2e, 0x4d, 0x75, 0x6c, 0x74, 0x69, 0x70, 0x6c, 0x79,
+	0x56, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x27, 0x0a, 0x06, 0x44, 0x69, 0x76, 0x69, 0x64, 0x65, 0x12,
+	0x0b, 0x2e, 0x74, 0x6f, 0x79, 0x2e, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x73, 0x1a, 0x10, 0x2e, 0x74,
+	0x6f, 0x79, 0x2e, 0x44, 0x69, 0x76, 0x69, 0x64, 0x65, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x32,
+	0x0a, 0x08, 0x45, 0x76, 0x61, 0x6c, 0x75, 0x61, 0x74, 0x65, 0x12, 0x0e, 0x2e, 0x74, 0x6f, 0x79,
+	0x2e, 0x4f, 0x70, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x1a, 0x12, 0x2e, 0x74, 0x6f, 0x79,
+	0x2e, 0x45, 0x76, 0x61, 0x6c, 0x75, 0x61, 0x74, 0x65, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x28, 0x01,
+	0x30, 0x01, 0x12, 0x3b, 0x0a, 0x12, 0x45, 0x76, 0x61, 0x6c, 0x75, 0x61, 0x74, 0x65, 0x45, 0x78,
+	0x70, 0x72, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x0f, 0x2e, 0x74, 0x6f, 0x79, 0x2e, 0x45,
+	0x78, 0x70, 0x72, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x1a, 0x14, 0x2e, 0x74, 0x6f, 0x79, 0x2e,
+	0x45, 0x78, 0x70, 0x72, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x42,
+	0x17, 0x5a, 0x15, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2f, 0x63, 0x61, 0x6c, 0x63, 0x75, 0x6c,
+	0x61, 0x74, 0x6f, 0x72, 0x67, 0x72, 0x70, 0x63, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -203,22 +583,39 @@ func file_schema_calculator_proto_rawDescGZIP() []byte {
 	return file_schema_calculator_proto_rawDescData
 }
 
-var file_schema_calculator_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_schema_calculator_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_schema_calculator_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
 var file_schema_calculator_proto_goTypes = []interface{}{
-	(*Values)(nil),         // 0: toy.Values
-	(*SubstractValue)(nil), // 1: toy.SubstractValue
-	(*AddValue)(nil),       // 2: toy.AddValue
+	(Operation_Op)(0),       // 0: toy.Operation.Op
+	(*Values)(nil),          // 1: toy.Values
+	(*SubstractValue)(nil),  // 2: toy.SubstractValue
+	(*AddValue)(nil),        // 3: toy.AddValue
+	(*MultiplyValue)(nil),   // 4: toy.MultiplyValue
+	(*DivideValue)(nil),     // 5: toy.DivideValue
+	(*Operation)(nil),       // 6: toy.Operation
+	(*EvaluateValue)(nil),   // 7: toy.EvaluateValue
+	(*Expression)(nil),      // 8: toy.Expression
+	(*ExpressionValue)(nil), // 9: toy.ExpressionValue
 }
 var file_schema_calculator_proto_depIdxs = []int32{
-	0, // 0: toy.Calculator.Substract:input_type -> toy.Values
-	0, // 1: toy.Calculator.Add:input_type -> toy.Values
-	1, // 2: toy.Calculator.Substract:output_type -> toy.SubstractValue
-	2, // 3: toy.Calculator.Add:output_type -> toy.AddValue
-	2, // [2:4] is the sub-list for method output_type
-	0, // [0:2] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	0, // 0: toy.Operation.op:type_name -> toy.Operation.Op
+	1, // 1: toy.Calculator.Substract:input_type -> toy.Values
+	1, // 2: toy.Calculator.Add:input_type -> toy.Values
+	1, // 3: toy.Calculator.Multiply:input_type -> toy.Values
+	1, // 4: toy.Calculator.Divide:input_type -> toy.Values
+	6, // 5: toy.Calculator.Evaluate:input_type -> toy.Operation
+	8, // 6: toy.Calculator.EvaluateExpression:input_type -> toy.Expression
+	2, // 7: toy.Calculator.Substract:output_type -> toy.SubstractValue
+	3, // 8: toy.Calculator.Add:output_type -> toy.AddValue
+	4, // 9: toy.Calculator.Multiply:output_type -> toy.MultiplyValue
+	5, // 10: toy.Calculator.Divide:output_type -> toy.DivideValue
+	7, // 11: toy.Calculator.Evaluate:output_type -> toy.EvaluateValue
+	9, // 12: toy.Calculator.EvaluateExpression:output_type -> toy.ExpressionValue
+	7, // [7:13] is the sub-list for method output_type
+	1, // [1:7] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
 }
 
 func init() { file_schema_calculator_proto_init() }
@@ -263,19 +660,92 @@ func file_schema_calculator_proto_init() {
 				return nil
 			}
 		}
+		file_schema_calculator_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*MultiplyValue); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_schema_calculator_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DivideValue); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_schema_calculator_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Operation); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_schema_calculator_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*EvaluateValue); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_schema_calculator_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Expression); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_schema_calculator_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ExpressionValue); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_schema_calculator_proto_rawDesc,
-			NumEnums:      0,
-			NumMessages:   3,
+			NumEnums:      1,
+			NumMessages:   9,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_schema_calculator_proto_goTypes,
 		DependencyIndexes: file_schema_calculator_proto_depIdxs,
+		EnumInfos:         file_schema_calculator_proto_enumTypes,
 		MessageInfos:      file_schema_calculator_proto_msgTypes,
 	}.Build()
 	File_schema_calculator_proto = out.File
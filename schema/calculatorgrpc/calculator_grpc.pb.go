@@ -20,6 +20,15 @@ const _ = grpc.SupportPackageIsVersion7
 type CalculatorClient interface {
 	Substract(ctx context.Context, in *Values, opts ...grpc.CallOption) (*SubstractValue, error)
 	Add(ctx context.Context, in *Values, opts ...grpc.CallOption) (*AddValue, error)
+	Multiply(ctx context.Context, in *Values, opts ...grpc.CallOption) (*MultiplyValue, error)
+	Divide(ctx context.Context, in *Values, opts ...grpc.CallOption) (*DivideValue, error)
+	// Evaluate applies each received operation to the running result, which
+	// starts at 0, and sends the result back after every operation. The
+	// stream ends with an error when an operation fails.
+	Evaluate(ctx context.Context, opts ...grpc.CallOption) (Calculator_EvaluateClient, error)
+	// EvaluateExpression evaluates an infix expression of int32 numbers,
+	// + - * / and parentheses, such as "(3 + 4) * 2 - 10 / 5".
+	EvaluateExpression(ctx context.Context, in *Expression, opts ...grpc.CallOption) (*ExpressionValue, error)
 }
 
 type calculatorClient struct {
@@ -48,12 +57,79 @@ func (c *calculatorClient) Add(ctx context.Context, in *Values, opts ...grpc.Cal
 	return out, nil
 }
 
+func (c *calculatorClient) Multiply(ctx context.Context, in *Values, opts ...grpc.CallOption) (*MultiplyValue, error) {
+	out := new(MultiplyValue)
+	err := c.cc.Invoke(ctx, "/toy.Calculator/Multiply", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calculatorClient) Divide(ctx context.Context, in *Values, opts ...grpc.CallOption) (*DivideValue, error) {
+	out := new(DivideValue)
+	err := c.cc.Invoke(ctx, "/toy.Calculator/Divide", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calculatorClient) Evaluate(ctx context.Context, opts ...grpc.CallOption) (Calculator_EvaluateClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Calculator_ServiceDesc.Streams[0], "/toy.Calculator/Evaluate", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &calculatorEvaluateClient{stream}
+	return x, nil
+}
+
+type Calculator_EvaluateClient interface {
+	Send(*Operation) error
+	Recv() (*EvaluateValue, error)
+	grpc.ClientStream
+}
+
+type calculatorEvaluateClient struct {
+	grpc.ClientStream
+}
+
+func (x *calculatorEvaluateClient) Send(m *Operation) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *calculatorEvaluateClient) Recv() (*EvaluateValue, error) {
+	m := new(EvaluateValue)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *calculatorClient) EvaluateExpression(ctx context.Context, in *Expression, opts ...grpc.CallOption) (*ExpressionValue, error) {
+	out := new(ExpressionValue)
+	err := c.cc.Invoke(ctx, "/toy.Calculator/EvaluateExpression", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // CalculatorServer is the server API for Calculator service.
 // All implementations must embed UnimplementedCalculatorServer
 // for forward compatibility
 type CalculatorServer interface {
 	Substract(context.Context, *Values) (*SubstractValue, error)
 	Add(context.Context, *Values) (*AddValue, error)
+	Multiply(context.Context, *Values) (*MultiplyValue, error)
+	Divide(context.Context, *Values) (*DivideValue, error)
+	// Evaluate applies each received operation to the running result, which
+	// starts at 0, and sends the result back after every operation. The
+	// stream ends with an error when an operation fails.
+	Evaluate(Calculator_EvaluateServer) error
+	// EvaluateExpression evaluates an infix expression of int32 numbers,
+	// + - * / and parentheses, such as "(3 + 4) * 2 - 10 / 5".
+	EvaluateExpression(context.Context, *Expression) (*ExpressionValue, error)
 	mustEmbedUnimplementedCalculatorServer()
 }
 
@@ -67,6 +143,18 @@ func (UnimplementedCalculatorServer) Substract(context.Context, *Values) (*Subst
 func (UnimplementedCalculatorServer) Add(context.Context, *Values) (*AddValue, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Add not implemented")
 }
+func (UnimplementedCalculatorServer) Multiply(context.Context, *Values) (*MultiplyValue, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Multiply not implemented")
+}
+func (UnimplementedCalculatorServer) Divide(context.Context, *Values) (*DivideValue, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Divide not implemented")
+}
+func (UnimplementedCalculatorServer) Evaluate(Calculator_EvaluateServer) error {
+	return status.Errorf(codes.Unimplemented, "method Evaluate not implemented")
+}
+func (UnimplementedCalculatorServer) EvaluateExpression(context.Context, *Expression) (*ExpressionValue, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method EvaluateExpression not implemented")
+}
 func (UnimplementedCalculatorServer) mustEmbedUnimplementedCalculatorServer() {}
 
 // UnsafeCalculatorServer may be embedded to opt out of forward compatibility for this service.
@@ -116,6 +204,86 @@ func _Calculator_Add_Handler(srv interface{}, ctx context.Context, dec func(inte
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Calculator_Multiply_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Values)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalculatorServer).Multiply(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/toy.Calculator/Multiply",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalculatorServer).Multiply(ctx, req.(*Values))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Calculator_Divide_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Values)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalculatorServer).Divide(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/toy.Calculator/Divide",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalculatorServer).Divide(ctx, req.(*Values))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Calculator_Evaluate_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(CalculatorServer).Evaluate(&calculatorEvaluateServer{stream})
+}
+
+type Calculator_EvaluateServer interface {
+	Send(*EvaluateValue) error
+	Recv() (*Operation, error)
+	grpc.ServerStream
+}
+
+type calculatorEvaluateServer struct {
+	grpc.ServerStream
+}
+
+func (x *calculatorEvaluateServer) Send(m *EvaluateValue) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *calculatorEvaluateServer) Recv() (*Operation, error) {
+	m := new(Operation)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Calculator_EvaluateExpression_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Expression)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalculatorServer).EvaluateExpression(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/toy.Calculator/EvaluateExpression",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalculatorServer).EvaluateExpression(ctx, req.(*Expression))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Calculator_ServiceDesc is the grpc.ServiceDesc for Calculator service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -131,7 +299,26 @@ var Calculator_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Add",
 			Handler:    _Calculator_Add_Handler,
 		},
+		{
+			MethodName: "Multiply",
+			Handler:    _Calculator_Multiply_Handler,
+		},
+		{
+			MethodName: "Divide",
+			Handler:    _Calculator_Divide_Handler,
+		},
+		{
+			MethodName: "EvaluateExpression",
+			Handler:    _Calculator_EvaluateExpression_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Evaluate",
+			Handler:       _Calculator_Evaluate_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "schema/calculator.proto",
 }